@@ -0,0 +1,329 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// defaultAPIPort is the local API's port unless the user picks another.
+const defaultAPIPort = 8765
+
+// maxAPIWrite bounds the body of a write request.
+const maxAPIWrite = 64 * 1024
+
+// MonitorControl is the part of the monitor the HTTP API reads and drives.
+// The UI implements it; tests can substitute a fake.
+type MonitorControl interface {
+	Ports() []string
+	Status() APIStatus
+	Connect(port string, baudRate int, framing Framing) error
+	Disconnect()
+	Write(data []byte) (int, error)
+	Lines() []SerialLine
+	Subscribe() (<-chan SerialLine, func())
+}
+
+// APIStatus is the connection state reported by GET /api/status.
+type APIStatus struct {
+	Connected bool   `json:"connected"`
+	Port      string `json:"port,omitempty"`
+	BaudRate  int    `json:"baudRate,omitempty"`
+}
+
+// apiLine is a SerialLine as sent to API clients.
+type apiLine struct {
+	Seq       uint64     `json:"seq"`
+	Timestamp time.Time  `json:"timestamp"`
+	FirstByte *time.Time `json:"firstByte,omitempty"`
+	Data      string     `json:"data"`
+}
+
+func newAPILine(line SerialLine) apiLine {
+	l := apiLine{Seq: line.Seq, Timestamp: line.Timestamp, Data: line.Data}
+	if !line.FirstByte.IsZero() {
+		l.FirstByte = &line.FirstByte
+	}
+	return l
+}
+
+// apiConnectRequest is the body of POST /api/connect.
+type apiConnectRequest struct {
+	Port     string   `json:"port"`
+	BaudRate int      `json:"baudRate"`
+	Framing  *Framing `json:"framing,omitempty"` // 8N1 if omitted
+}
+
+// newAPIToken returns a random token for authenticating API clients.
+func newAPIToken() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+type apiHandler struct {
+	ctrl     MonitorControl
+	token    string
+	upgrader websocket.Upgrader
+}
+
+// NewAPIHandler returns the HTTP API for a monitor. Every request must carry
+// the token, as "Authorization: Bearer <token>" or, for browser EventSource
+// and WebSocket clients that can't set headers, a "token" query parameter.
+//
+//	GET  /api/status      connection state
+//	GET  /api/ports       available serial ports
+//	POST /api/connect     open a port: {"port", "baudRate", "framing"}
+//	POST /api/disconnect  close the port
+//	POST /api/write       write the request body to the port as-is
+//	GET  /api/lines       buffered lines as JSON Lines, or CSV with ?format=csv
+//	GET  /api/events      live lines as Server-Sent Events
+//	GET  /api/ws          live lines over WebSocket; text sent by the client is written to the port
+func NewAPIHandler(ctrl MonitorControl, token string) http.Handler {
+	h := &apiHandler{
+		ctrl:  ctrl,
+		token: token,
+		// The token, not the origin, decides who may connect.
+		upgrader: websocket.Upgrader{CheckOrigin: func(*http.Request) bool { return true }},
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/status", h.status)
+	mux.HandleFunc("GET /api/ports", h.ports)
+	mux.HandleFunc("POST /api/connect", h.connect)
+	mux.HandleFunc("POST /api/disconnect", h.disconnect)
+	mux.HandleFunc("POST /api/write", h.write)
+	mux.HandleFunc("GET /api/lines", h.lines)
+	mux.HandleFunc("GET /api/events", h.events)
+	mux.HandleFunc("GET /api/ws", h.websocket)
+	return h.authorize(mux)
+}
+
+// authorize rejects requests without the token.
+func (h *apiHandler) authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := r.URL.Query().Get("token")
+		if auth, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+			token = auth
+		}
+		if h.token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(h.token)) != 1 {
+			apiError(w, http.StatusUnauthorized, fmt.Errorf("missing or invalid token"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func apiJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func apiError(w http.ResponseWriter, code int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
+
+func (h *apiHandler) status(w http.ResponseWriter, r *http.Request) {
+	apiJSON(w, h.ctrl.Status())
+}
+
+func (h *apiHandler) ports(w http.ResponseWriter, r *http.Request) {
+	apiJSON(w, h.ctrl.Ports())
+}
+
+func (h *apiHandler) connect(w http.ResponseWriter, r *http.Request) {
+	var req apiConnectRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apiError(w, http.StatusBadRequest, fmt.Errorf("failed to parse request: %w", err))
+		return
+	}
+	if req.Port == "" || req.BaudRate <= 0 {
+		apiError(w, http.StatusBadRequest, fmt.Errorf("port and baudRate are required"))
+		return
+	}
+	framing := defaultFraming
+	if req.Framing != nil {
+		framing = *req.Framing
+	}
+	if err := h.ctrl.Connect(req.Port, req.BaudRate, framing); err != nil {
+		apiError(w, http.StatusConflict, err)
+		return
+	}
+	apiJSON(w, h.ctrl.Status())
+}
+
+func (h *apiHandler) disconnect(w http.ResponseWriter, r *http.Request) {
+	h.ctrl.Disconnect()
+	apiJSON(w, h.ctrl.Status())
+}
+
+func (h *apiHandler) write(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxAPIWrite))
+	if err != nil {
+		apiError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("failed to read request: %w", err))
+		return
+	}
+	n, err := h.ctrl.Write(data)
+	if err != nil {
+		apiError(w, http.StatusConflict, err)
+		return
+	}
+	apiJSON(w, map[string]int{"written": n})
+}
+
+func (h *apiHandler) lines(w http.ResponseWriter, r *http.Request) {
+	lines := h.ctrl.Lines()
+	switch r.URL.Query().Get("format") {
+	case "", "jsonl":
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.Header().Set("Content-Disposition", `attachment; filename="serial_data.jsonl"`)
+		enc := json.NewEncoder(w)
+		for _, line := range lines {
+			enc.Encode(newAPILine(line))
+		}
+	case "csv":
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", `attachment; filename="serial_data.csv"`)
+		cw := csv.NewWriter(w)
+		cw.Write([]string{"Seq", "Timestamp", "Data"})
+		for _, line := range lines {
+			cw.Write([]string{fmt.Sprint(line.Seq), line.Timestamp.Format(time.RFC3339Nano), line.Data})
+		}
+		cw.Flush()
+	default:
+		apiError(w, http.StatusBadRequest, fmt.Errorf("unknown format %q", r.URL.Query().Get("format")))
+	}
+}
+
+// events streams lines as Server-Sent Events until the client goes away.
+func (h *apiHandler) events(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		apiError(w, http.StatusInternalServerError, fmt.Errorf("streaming not supported"))
+		return
+	}
+	lines, unsubscribe := h.ctrl.Subscribe()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(15 * time.Second)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		case line, ok := <-lines:
+			if !ok {
+				return
+			}
+			data, _ := json.Marshal(newAPILine(line))
+			fmt.Fprintf(w, "id: %d\ndata: %s\n\n", line.Seq, data)
+		}
+		flusher.Flush()
+	}
+}
+
+// websocket streams lines as JSON text messages and writes messages from
+// the client to the port.
+func (h *apiHandler) websocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return // the upgrader has already replied
+	}
+	defer conn.Close()
+
+	lines, unsubscribe := h.ctrl.Subscribe()
+	defer unsubscribe()
+
+	// The reader runs until the client closes the connection.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		conn.SetReadLimit(maxAPIWrite)
+		for {
+			_, msg, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			h.ctrl.Write(msg)
+		}
+	}()
+
+	ping := time.NewTicker(30 * time.Second)
+	defer ping.Stop()
+	for {
+		var err error
+		select {
+		case <-done:
+			return
+		case <-r.Context().Done():
+			conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, ""))
+			return
+		case <-ping.C:
+			err = conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(5*time.Second))
+		case line, ok := <-lines:
+			if !ok {
+				return
+			}
+			err = conn.WriteJSON(newAPILine(line))
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// APIServer serves the HTTP API on localhost.
+type APIServer struct {
+	srv    *http.Server
+	addr   string
+	cancel context.CancelFunc
+}
+
+// StartAPIServer listens on 127.0.0.1 at the given port and serves handler
+// in the background.
+func StartAPIServer(port int, handler http.Handler) (*APIServer, error) {
+	ln, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on port %d: %w", port, err)
+	}
+	// Streaming handlers watch the request context, which is cancelled
+	// when the server closes.
+	ctx, cancel := context.WithCancel(context.Background())
+	srv := &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return ctx },
+	}
+	go srv.Serve(ln)
+	return &APIServer{srv: srv, addr: ln.Addr().String(), cancel: cancel}, nil
+}
+
+// Addr returns the address the server listens on.
+func (s *APIServer) Addr() string {
+	return s.addr
+}
+
+// Close stops the server and ends open streams.
+func (s *APIServer) Close() error {
+	s.cancel()
+	return s.srv.Close()
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testToken = "secret"
+
+// fakeMonitor is a MonitorControl that records calls instead of opening ports.
+type fakeMonitor struct {
+	mu         sync.Mutex
+	lines      []SerialLine
+	connected  APIStatus
+	connects   int
+	subscribed chan chan SerialLine // receives each new subscription
+}
+
+func newFakeMonitor(lines ...SerialLine) *fakeMonitor {
+	return &fakeMonitor{lines: lines, subscribed: make(chan chan SerialLine, 1)}
+}
+
+func (m *fakeMonitor) Ports() []string { return []string{"/dev/ttyFAKE"} }
+
+func (m *fakeMonitor) Status() APIStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.connected
+}
+
+func (m *fakeMonitor) Connect(port string, baudRate int, framing Framing) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.connects++
+	m.connected = APIStatus{Connected: true, Port: port, BaudRate: baudRate}
+	return nil
+}
+
+func (m *fakeMonitor) Disconnect() {
+	m.mu.Lock()
+	m.connected = APIStatus{}
+	m.mu.Unlock()
+}
+
+func (m *fakeMonitor) Write(data []byte) (int, error) { return len(data), nil }
+
+func (m *fakeMonitor) Lines() []SerialLine { return m.lines }
+
+func (m *fakeMonitor) Subscribe() (<-chan SerialLine, func()) {
+	ch := make(chan SerialLine, 1)
+	m.subscribed <- ch
+	return ch, func() {}
+}
+
+// apiRequest sends a request with the test token in the Authorization header.
+func apiRequest(t *testing.T, h http.Handler, method, target, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+testToken)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAPIRejectsBadToken(t *testing.T) {
+	h := NewAPIHandler(newFakeMonitor(), testToken)
+	for _, tc := range []struct {
+		name   string
+		target string
+		header string
+		want   int
+	}{
+		{"no token", "/api/status", "", http.StatusUnauthorized},
+		{"wrong header", "/api/status", "Bearer nope", http.StatusUnauthorized},
+		{"wrong query", "/api/status?token=nope", "", http.StatusUnauthorized},
+		{"header", "/api/status", "Bearer " + testToken, http.StatusOK},
+		{"query", "/api/status?token=" + testToken, "", http.StatusOK},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tc.target, nil)
+			if tc.header != "" {
+				req.Header.Set("Authorization", tc.header)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if rec.Code != tc.want {
+				t.Errorf("status %d, want %d", rec.Code, tc.want)
+			}
+		})
+	}
+
+	// An empty token must never authorize anything.
+	rec := httptest.NewRecorder()
+	NewAPIHandler(newFakeMonitor(), "").ServeHTTP(rec, httptest.NewRequest("GET", "/api/status?token=", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("empty token: status %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestAPILines(t *testing.T) {
+	ts := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	h := NewAPIHandler(newFakeMonitor(
+		SerialLine{Seq: 1, Timestamp: ts, Data: "a,1"},
+		SerialLine{Seq: 2, Timestamp: ts.Add(time.Second), Data: `b "2"`},
+	), testToken)
+
+	rec := apiRequest(t, h, "GET", "/api/lines", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("jsonl: status %d", rec.Code)
+	}
+	var got []apiLine
+	dec := json.NewDecoder(rec.Body)
+	for dec.More() {
+		var l apiLine
+		if err := dec.Decode(&l); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, l)
+	}
+	if len(got) != 2 || got[0].Seq != 1 || got[1].Data != `b "2"` || !got[1].Timestamp.Equal(ts.Add(time.Second)) {
+		t.Errorf("jsonl: got %+v", got)
+	}
+
+	rec = apiRequest(t, h, "GET", "/api/lines?format=csv", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("csv: status %d", rec.Code)
+	}
+	records, err := csv.NewReader(rec.Body).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"Seq", "Timestamp", "Data"},
+		{"1", ts.Format(time.RFC3339Nano), "a,1"},
+		{"2", ts.Add(time.Second).Format(time.RFC3339Nano), `b "2"`},
+	}
+	if fmt.Sprint(records) != fmt.Sprint(want) {
+		t.Errorf("csv: got %q, want %q", records, want)
+	}
+
+	if rec := apiRequest(t, h, "GET", "/api/lines?format=xml", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("unknown format: status %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestAPIConnectValidation(t *testing.T) {
+	m := newFakeMonitor()
+	h := NewAPIHandler(m, testToken)
+	for _, body := range []string{`not json`, `{}`, `{"port":"/dev/ttyFAKE"}`, `{"baudRate":9600}`, `{"port":"/dev/ttyFAKE","baudRate":-1}`} {
+		if rec := apiRequest(t, h, "POST", "/api/connect", body); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want %d", body, rec.Code, http.StatusBadRequest)
+		}
+	}
+	if m.connects != 0 {
+		t.Fatalf("invalid requests connected %d times", m.connects)
+	}
+
+	rec := apiRequest(t, h, "POST", "/api/connect", `{"port":"/dev/ttyFAKE","baudRate":115200}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("valid request: status %d: %s", rec.Code, rec.Body)
+	}
+	var status APIStatus
+	if err := json.NewDecoder(rec.Body).Decode(&status); err != nil {
+		t.Fatal(err)
+	}
+	if want := (APIStatus{Connected: true, Port: "/dev/ttyFAKE", BaudRate: 115200}); status != want {
+		t.Errorf("status %+v, want %+v", status, want)
+	}
+}
+
+func TestAPIEventsStreamsLine(t *testing.T) {
+	m := newFakeMonitor()
+	srv := httptest.NewServer(NewAPIHandler(m, testToken))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/api/events?token=" + testToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("content type %q", ct)
+	}
+
+	select {
+	case ch := <-m.subscribed:
+		ch <- SerialLine{Seq: 7, Timestamp: time.Now(), Data: "hello"}
+	case <-time.After(5 * time.Second):
+		t.Fatal("handler never subscribed")
+	}
+
+	// Read one event: an id line, a data line and a blank line.
+	r := bufio.NewReader(resp.Body)
+	var event []string
+	for {
+		s, err := r.ReadString('\n')
+		if err != nil && err != io.EOF {
+			t.Fatal(err)
+		}
+		s = strings.TrimRight(s, "\n")
+		if s == "" {
+			break
+		}
+		event = append(event, s)
+	}
+	if len(event) != 2 || event[0] != "id: 7" || !strings.HasPrefix(event[1], "data: ") {
+		t.Fatalf("event %q", event)
+	}
+	var line apiLine
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(event[1], "data: ")), &line); err != nil {
+		t.Fatal(err)
+	}
+	if line.Seq != 7 || line.Data != "hello" {
+		t.Errorf("line %+v", line)
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// uiControl lets the HTTP API drive the monitor through the UI, so the
+// toolbar always reflects what API clients do.
+type uiControl struct {
+	ui *AppUI
+}
+
+func (c uiControl) Ports() []string {
+	return c.ui.serial.AvailablePorts()
+}
+
+func (c uiControl) Status() APIStatus {
+	if !c.ui.connected.Load() {
+		return APIStatus{}
+	}
+	return APIStatus{Connected: true, Port: c.ui.serial.PortName(), BaudRate: c.ui.serial.BaudRate()}
+}
+
+func (c uiControl) Connect(port string, baudRate int, framing Framing) error {
+	if _, err := framing.mode(baudRate); err != nil {
+		return err
+	}
+	var err error
+	fyne.DoAndWait(func() {
+		ui := c.ui
+		if ui.connected.Load() {
+			err = fmt.Errorf("already connected to %s", ui.serial.PortName())
+			return
+		}
+		if !slices.Contains(ui.portSelect.Options, port) {
+			ui.portSelect.Options = append(ui.portSelect.Options, port)
+		}
+		ui.portSelect.SetSelected(port)
+		baud := strconv.Itoa(baudRate)
+		if !slices.Contains(ui.baudSelect.Options, baud) {
+			ui.baudSelect.Options = append(ui.baudSelect.Options, baud)
+		}
+		ui.baudSelect.SetSelected(baud)
+		ui.setFraming(framing)
+		err = ui.connect(port, baudRate, framing)
+	})
+	return err
+}
+
+func (c uiControl) Disconnect() {
+	fyne.DoAndWait(func() {
+		if c.ui.connected.Load() {
+			c.ui.serial.Disconnect()
+			c.ui.setDisconnectedState()
+		}
+	})
+}
+
+func (c uiControl) Write(data []byte) (int, error) {
+	return c.ui.serial.Write(data)
+}
+
+func (c uiControl) Lines() []SerialLine {
+	c.ui.mu.Lock()
+	defer c.ui.mu.Unlock()
+	return slices.Clone(c.ui.lines)
+}
+
+func (c uiControl) Subscribe() (<-chan SerialLine, func()) {
+	return c.ui.lineHub.Subscribe()
+}
+
+// startAPI starts the local API with the saved port and token, generating a
+// token on first use.
+func (ui *AppUI) startAPI() error {
+	ui.stopAPI()
+	if ui.settings.API.Token == "" {
+		ui.settings.API.Token = newAPIToken()
+	}
+	server, err := StartAPIServer(ui.settings.API.Port, NewAPIHandler(uiControl{ui}, ui.settings.API.Token))
+	if err != nil {
+		return err
+	}
+	ui.api = server
+	return nil
+}
+
+// stopAPI stops the local API if it is running.
+func (ui *AppUI) stopAPI() {
+	if ui.api != nil {
+		ui.api.Close()
+		ui.api = nil
+	}
+}
+
+// showAPIDialog configures the local HTTP API.
+func (ui *AppUI) showAPIDialog() {
+	enabledCheck := widget.NewCheck("Serve the API on localhost", nil)
+	enabledCheck.SetChecked(ui.settings.API.Enabled)
+	portEntry := widget.NewEntry()
+	portEntry.SetText(strconv.Itoa(ui.settings.API.Port))
+
+	token := ui.settings.API.Token
+	if token == "" {
+		token = newAPIToken()
+	}
+	tokenEntry := widget.NewEntry()
+	tokenEntry.SetText(token)
+	newTokenBtn := widget.NewButton("New", func() {
+		tokenEntry.SetText(newAPIToken())
+	})
+	copyTokenBtn := widget.NewButton("Copy", func() {
+		fyne.CurrentApp().Clipboard().SetContent(tokenEntry.Text)
+	})
+
+	status := "Stopped"
+	if ui.api != nil {
+		status = "Listening on http://" + ui.api.Addr()
+	}
+	help := widget.NewLabel("Send the token as \"Authorization: Bearer <token>\" or ?token=.\n" +
+		"Endpoints: /api/status, /api/ports, /api/connect, /api/disconnect,\n" +
+		"/api/write, /api/lines, /api/events (SSE), /api/ws (WebSocket)")
+
+	form := widget.NewForm(
+		widget.NewFormItem("Enabled", enabledCheck),
+		widget.NewFormItem("Port", portEntry),
+		widget.NewFormItem("Token", container.NewBorder(nil, nil, nil, container.NewHBox(newTokenBtn, copyTokenBtn), tokenEntry)),
+		widget.NewFormItem("Status", widget.NewLabel(status)),
+	)
+
+	dialog.ShowCustomConfirm("Local API", "Apply", "Cancel", container.NewVBox(form, help), func(ok bool) {
+		if !ok {
+			return
+		}
+		port, err := strconv.Atoi(portEntry.Text)
+		if err != nil || port <= 0 || port > 65535 {
+			dialog.ShowError(fmt.Errorf("invalid port: %s", portEntry.Text), ui.window)
+			return
+		}
+		if tokenEntry.Text == "" {
+			dialog.ShowError(fmt.Errorf("the token must not be empty"), ui.window)
+			return
+		}
+		ui.settings.API = APISettings{Enabled: enabledCheck.Checked, Port: port, Token: tokenEntry.Text}
+		ui.saveSettings()
+
+		ui.stopAPI()
+		if !enabledCheck.Checked {
+			return
+		}
+		if err := ui.startAPI(); err != nil {
+			dialog.ShowError(err, ui.window)
+		}
+	}, ui.window)
+}
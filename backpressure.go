@@ -0,0 +1,231 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// What the reader does when the consumer's line buffer is full.
+const (
+	BackpressureBlock      = "Block"       // wait for room; the OS buffer fills meanwhile
+	BackpressureDropOldest = "Drop oldest" // discard the oldest buffered line
+	BackpressureSpill      = "Spill to disk"
+)
+
+var backpressurePolicies = []string{BackpressureBlock, BackpressureDropOldest, BackpressureSpill}
+
+// ErrorCounters are the receive error counts kept by the serial driver.
+type ErrorCounters struct {
+	Overrun       uint64 // characters lost because the UART's FIFO was full
+	BufferOverrun uint64 // characters lost because the driver's buffer was full
+	Frame         uint64
+	Parity        uint64
+	Break         uint64
+}
+
+// sub returns the counts added since base.
+func (c ErrorCounters) sub(base ErrorCounters) ErrorCounters {
+	return ErrorCounters{
+		Overrun:       c.Overrun - base.Overrun,
+		BufferOverrun: c.BufferOverrun - base.BufferOverrun,
+		Frame:         c.Frame - base.Frame,
+		Parity:        c.Parity - base.Parity,
+		Break:         c.Break - base.Break,
+	}
+}
+
+// LinkHealth reports how well the consumer keeps up with the port during the
+// current or last connection.
+type LinkHealth struct {
+	Policy      string
+	ChannelFull uint64        // times a line found the consumer's buffer full
+	Stalled     time.Duration // time the reader spent waiting for room
+	Dropped     uint64        // lines discarded by the drop-oldest policy or a failed spill
+	Spilled     uint64        // lines written to the spill file
+	Spooled     int           // lines in the spill file waiting for the consumer
+	Backlog     int           // lines waiting in the consumer's buffer
+	OS          ErrorCounters // driver counts since connecting
+	OSErr       error         // why driver counts are unavailable, if they are
+}
+
+// backpressureCounters are updated by the reader and read by Health.
+type backpressureCounters struct {
+	channelFull atomic.Uint64
+	stalled     atomic.Int64 // nanoseconds
+	dropped     atomic.Uint64
+	spilled     atomic.Uint64
+	spooled     atomic.Int64
+}
+
+func (c *backpressureCounters) reset() {
+	c.channelFull.Store(0)
+	c.stalled.Store(0)
+	c.dropped.Store(0)
+	c.spilled.Store(0)
+	c.spooled.Store(0)
+}
+
+// lineSender hands lines from the reader to the consumer's channel under a
+// backpressure policy.
+type lineSender struct {
+	ch       chan SerialLine
+	stop     <-chan struct{}
+	policy   string
+	counters *backpressureCounters
+	spool    *lineSpool // for BackpressureSpill
+}
+
+// send delivers a line. It returns false if the reader was asked to stop.
+func (s *lineSender) send(line SerialLine) bool {
+	if s.spool != nil {
+		return s.spool.push(line)
+	}
+	select {
+	case s.ch <- line:
+		return true
+	default:
+	}
+	s.counters.channelFull.Add(1)
+
+	if s.policy == BackpressureDropOldest {
+		for {
+			select {
+			case <-s.ch:
+				s.counters.dropped.Add(1)
+			default:
+			}
+			select {
+			case s.ch <- line:
+				return true
+			default:
+			}
+		}
+	}
+
+	start := time.Now()
+	defer func() { s.counters.stalled.Add(int64(time.Since(start))) }()
+	select {
+	case s.ch <- line:
+		return true
+	case <-s.stop:
+		return false
+	}
+}
+
+// lineSpool queues lines in a temporary file while the consumer's buffer is
+// full, and feeds them to it in order as room frees up.
+type lineSpool struct {
+	mu       sync.Mutex
+	cond     *sync.Cond
+	file     *os.File
+	readOff  int64
+	writeOff int64
+	pending  []int // sizes of the spooled records, oldest first
+	finished bool  // the reader is done; deliver what's left and exit
+
+	ch       chan SerialLine
+	stop     <-chan struct{}
+	counters *backpressureCounters
+	done     chan struct{}
+}
+
+func newLineSpool(ch chan SerialLine, stop <-chan struct{}, counters *backpressureCounters) (*lineSpool, error) {
+	f, err := os.CreateTemp("", "serial-spill-*.jsonl")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create spill file: %w", err)
+	}
+	s := &lineSpool{file: f, ch: ch, stop: stop, counters: counters, done: make(chan struct{})}
+	s.cond = sync.NewCond(&s.mu)
+	go s.drain()
+	return s, nil
+}
+
+// push sends a line straight to the consumer when nothing is spooled and
+// there is room, and spools it otherwise.
+func (s *lineSpool) push(line SerialLine) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.pending) == 0 {
+		select {
+		case s.ch <- line:
+			return true
+		default:
+		}
+		s.counters.channelFull.Add(1)
+	}
+	data, err := json.Marshal(line)
+	if err == nil {
+		data = append(data, '\n')
+		_, err = s.file.WriteAt(data, s.writeOff)
+	}
+	if err != nil {
+		s.counters.dropped.Add(1)
+		return true
+	}
+	s.writeOff += int64(len(data))
+	s.pending = append(s.pending, len(data))
+	s.counters.spilled.Add(1)
+	s.counters.spooled.Add(1)
+	s.cond.Signal()
+	return true
+}
+
+// drain feeds spooled lines to the consumer until stopped, or until the
+// reader has finished and the spool is empty.
+func (s *lineSpool) drain() {
+	defer close(s.done)
+	for {
+		s.mu.Lock()
+		for len(s.pending) == 0 && !s.finished {
+			s.cond.Wait()
+		}
+		if len(s.pending) == 0 {
+			s.mu.Unlock()
+			return
+		}
+		data := make([]byte, s.pending[0])
+		_, err := s.file.ReadAt(data, s.readOff)
+		s.mu.Unlock()
+
+		var line SerialLine
+		if err == nil {
+			err = json.Unmarshal(data, &line)
+		}
+		if err == nil {
+			select {
+			case s.ch <- line:
+			case <-s.stop:
+				return
+			}
+		} else {
+			s.counters.dropped.Add(1)
+		}
+
+		s.mu.Lock()
+		s.pending = s.pending[1:]
+		s.readOff += int64(len(data))
+		s.counters.spooled.Add(-1)
+		if len(s.pending) == 0 {
+			// Start over so the file doesn't grow for the whole connection.
+			s.readOff, s.writeOff = 0, 0
+			s.file.Truncate(0)
+		}
+		s.mu.Unlock()
+	}
+}
+
+// close waits for spooled lines to be delivered, unless the reader was
+// stopped, and removes the spill file.
+func (s *lineSpool) close() {
+	s.mu.Lock()
+	s.finished = true
+	s.cond.Broadcast()
+	s.mu.Unlock()
+	<-s.done
+	s.file.Close()
+	os.Remove(s.file.Name())
+}
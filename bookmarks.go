@@ -0,0 +1,166 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// bookmarkCell is the export value for a bookmarked line: its note, or a
+// marker when it has none.
+func bookmarkCell(note string) string {
+	if note == "" {
+		return "bookmarked"
+	}
+	return note
+}
+
+// lineIndex returns the index of the line with the given Seq, or -1 if it
+// has scrolled out.
+func lineIndex(lines []SerialLine, seq uint64) int {
+	i, found := slices.BinarySearchFunc(lines, seq, func(l SerialLine, seq uint64) int {
+		switch {
+		case l.Seq < seq:
+			return -1
+		case l.Seq > seq:
+			return 1
+		}
+		return 0
+	})
+	if !found {
+		return -1
+	}
+	return i
+}
+
+// inSelectionLocked reports whether a line is within the selected range.
+// Must be called with ui.mu held.
+func (ui *AppUI) inSelectionLocked(line SerialLine) bool {
+	return ui.selStart != 0 && line.Seq >= ui.selStart && line.Seq <= ui.selEnd
+}
+
+// pruneBookmarksLocked drops bookmarks on lines that have left the buffer.
+// Must be called with ui.mu held.
+func (ui *AppUI) pruneBookmarksLocked() {
+	if len(ui.bookmarks) == 0 || len(ui.lines) == 0 {
+		return
+	}
+	first := ui.lines[0].Seq
+	for seq := range ui.bookmarks {
+		if seq < first {
+			delete(ui.bookmarks, seq)
+		}
+	}
+}
+
+// copySelection copies the selected lines to the clipboard, one per line,
+// optionally prefixed with their display timestamps.
+func (ui *AppUI) copySelection(withTimestamps bool) {
+	ui.mu.Lock()
+	var b strings.Builder
+	var prev time.Time
+	for _, line := range ui.lines {
+		if ui.inSelectionLocked(line) {
+			if withTimestamps {
+				fmt.Fprintf(&b, "[%s] ", formatDisplayTimestamp(line.Timestamp, ui.timestampMode, connectOrigin(ui.connects, line.Timestamp), prev))
+			}
+			b.WriteString(ui.lineTextLocked(line))
+			b.WriteString("\n")
+		}
+		prev = line.Timestamp
+	}
+	ui.mu.Unlock()
+
+	if b.Len() == 0 {
+		return
+	}
+	fyne.CurrentApp().Clipboard().SetContent(b.String())
+}
+
+// showCopyMenu offers copying the selection with or without timestamps.
+func (ui *AppUI) showCopyMenu() {
+	menu := fyne.NewMenu("",
+		fyne.NewMenuItem("Copy Lines", func() { ui.copySelection(false) }),
+		fyne.NewMenuItem("Copy Lines with Timestamps", func() { ui.copySelection(true) }),
+	)
+	widget.ShowPopUpMenuAtRelativePosition(menu, ui.window.Canvas(), fyne.NewPos(0, ui.copyBtn.Size().Height), ui.copyBtn)
+}
+
+// toggleBookmark adds or removes a bookmark on the last clicked line.
+func (ui *AppUI) toggleBookmark() {
+	ui.mu.Lock()
+	if lineIndex(ui.lines, ui.selCursor) >= 0 {
+		if _, ok := ui.bookmarks[ui.selCursor]; ok {
+			delete(ui.bookmarks, ui.selCursor)
+		} else {
+			ui.bookmarks[ui.selCursor] = ""
+		}
+	}
+	ui.mu.Unlock()
+	ui.output.Refresh()
+}
+
+// showNoteDialog edits the note on the last clicked line, bookmarking it.
+// Clearing the text keeps the bookmark without a note.
+func (ui *AppUI) showNoteDialog() {
+	ui.mu.Lock()
+	seq := ui.selCursor
+	ok := lineIndex(ui.lines, seq) >= 0
+	note := ui.bookmarks[seq]
+	ui.mu.Unlock()
+	if !ok {
+		dialog.ShowInformation("Note", "Click a line first.", ui.window)
+		return
+	}
+
+	entry := widget.NewEntry()
+	entry.SetText(note)
+	dialog.ShowForm("Note", "Save", "Cancel",
+		[]*widget.FormItem{widget.NewFormItem("Note", entry)},
+		func(confirmed bool) {
+			if !confirmed {
+				return
+			}
+			ui.mu.Lock()
+			if lineIndex(ui.lines, seq) >= 0 {
+				ui.bookmarks[seq] = strings.TrimSpace(entry.Text)
+			}
+			ui.mu.Unlock()
+			ui.output.Refresh()
+		}, ui.window)
+}
+
+// jumpToBookmark selects the next (dir > 0) or previous bookmarked line from
+// the last clicked line, wrapping around the buffer.
+func (ui *AppUI) jumpToBookmark(dir int) {
+	ui.mu.Lock()
+	lines, _ := ui.visibleLocked()
+	var seqs []uint64
+	for seq := range ui.bookmarks {
+		seqs = append(seqs, seq)
+	}
+	slices.Sort(seqs)
+	target := -1
+	if len(seqs) > 0 {
+		i, found := slices.BinarySearch(seqs, ui.selCursor)
+		switch {
+		case dir > 0 && found:
+			i++
+		case dir < 0:
+			i--
+		}
+		target = lineIndex(lines, seqs[(i+len(seqs))%len(seqs)])
+	}
+	ui.mu.Unlock()
+
+	if target < 0 {
+		return
+	}
+	ui.output.ScrollTo(target)
+	ui.output.Select(target)
+}
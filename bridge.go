@@ -0,0 +1,206 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Bridge write policies decide which TCP clients may write to the port.
+const (
+	BridgeWriteShared   = "Shared"       // every client; writes are serialized
+	BridgeWriteFirst    = "First client" // only the longest-connected client
+	BridgeWriteReadOnly = "Read-only"    // no client
+)
+
+var bridgeWritePolicies = []string{BridgeWriteShared, BridgeWriteFirst, BridgeWriteReadOnly}
+
+const defaultBridgePort = 7000
+
+// Bridge re-serves the open serial port on a TCP port, ser2net style: every
+// client receives the raw bytes read from the port, and bytes clients send
+// are written to it.
+type Bridge struct {
+	sm       *SerialManager
+	ln       net.Listener
+	policy   string
+	onChange func() // called when a client connects or disconnects
+
+	mu      sync.Mutex
+	clients []*bridgeClient // in connection order
+	closed  bool
+	wg      sync.WaitGroup
+}
+
+type bridgeClient struct {
+	conn      net.Conn
+	connected time.Time
+	bytesIn   atomic.Uint64 // from the client to the port
+	bytesOut  atomic.Uint64 // from the port to the client
+}
+
+// BridgeClientInfo describes a connected bridge client.
+type BridgeClientInfo struct {
+	Addr      string
+	Connected time.Time
+	BytesIn   uint64
+	BytesOut  uint64
+	CanWrite  bool
+}
+
+// StartBridge listens on the given TCP port, on localhost only unless
+// allInterfaces is set, and serves clients in the background.
+func StartBridge(sm *SerialManager, port int, allInterfaces bool, policy string, onChange func()) (*Bridge, error) {
+	host := "127.0.0.1"
+	if allInterfaces {
+		host = ""
+	}
+	ln, err := net.Listen("tcp", net.JoinHostPort(host, fmt.Sprint(port)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on port %d: %w", port, err)
+	}
+	if onChange == nil {
+		onChange = func() {}
+	}
+	b := &Bridge{sm: sm, ln: ln, policy: policy, onChange: onChange}
+	b.wg.Add(1)
+	go b.accept()
+	return b, nil
+}
+
+// Addr returns the address the bridge listens on.
+func (b *Bridge) Addr() string {
+	return b.ln.Addr().String()
+}
+
+// Close stops listening and disconnects every client.
+func (b *Bridge) Close() error {
+	b.mu.Lock()
+	b.closed = true
+	for _, c := range b.clients {
+		c.conn.Close()
+	}
+	b.mu.Unlock()
+	err := b.ln.Close()
+	b.wg.Wait()
+	return err
+}
+
+// Clients lists the connected clients in connection order.
+func (b *Bridge) Clients() []BridgeClientInfo {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	infos := make([]BridgeClientInfo, len(b.clients))
+	for i, c := range b.clients {
+		infos[i] = BridgeClientInfo{
+			Addr:      c.conn.RemoteAddr().String(),
+			Connected: c.connected,
+			BytesIn:   c.bytesIn.Load(),
+			BytesOut:  c.bytesOut.Load(),
+			CanWrite:  b.canWriteLocked(c),
+		}
+	}
+	return infos
+}
+
+// Kick disconnects the client with the given remote address.
+func (b *Bridge) Kick(addr string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, c := range b.clients {
+		if c.conn.RemoteAddr().String() == addr {
+			c.conn.Close()
+		}
+	}
+}
+
+// canWriteLocked applies the write policy. Must be called with b.mu held.
+func (b *Bridge) canWriteLocked(c *bridgeClient) bool {
+	switch b.policy {
+	case BridgeWriteReadOnly:
+		return false
+	case BridgeWriteFirst:
+		return len(b.clients) > 0 && b.clients[0] == c
+	}
+	return true
+}
+
+func (b *Bridge) accept() {
+	defer b.wg.Done()
+	for {
+		conn, err := b.ln.Accept()
+		if err != nil {
+			return // listener closed
+		}
+		c := &bridgeClient{conn: conn, connected: time.Now()}
+		b.mu.Lock()
+		if b.closed {
+			b.mu.Unlock()
+			conn.Close()
+			return
+		}
+		b.clients = append(b.clients, c)
+		b.wg.Add(1)
+		b.mu.Unlock()
+		b.onChange()
+		go b.serve(c)
+	}
+}
+
+// serve copies port data to the client and client data to the port until
+// either side closes.
+func (b *Bridge) serve(c *bridgeClient) {
+	defer b.wg.Done()
+	chunks, unsubscribe := b.sm.Tap()
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		buf := make([]byte, 4096)
+		for {
+			n, err := c.conn.Read(buf)
+			if n > 0 {
+				b.mu.Lock()
+				allowed := b.canWriteLocked(c)
+				b.mu.Unlock()
+				// Disallowed writes and writes while disconnected are dropped
+				// so a client can't stall the bridge.
+				if allowed {
+					if _, err := b.sm.Write(buf[:n]); err == nil {
+						c.bytesIn.Add(uint64(n))
+					}
+				}
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-done:
+			unsubscribe()
+			b.remove(c)
+			return
+		case chunk := <-chunks:
+			c.conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
+			n, err := c.conn.Write(chunk)
+			c.bytesOut.Add(uint64(n))
+			if err != nil {
+				c.conn.Close() // the reader exits and we clean up above
+			}
+		}
+	}
+}
+
+func (b *Bridge) remove(c *bridgeClient) {
+	c.conn.Close()
+	b.mu.Lock()
+	b.clients = slices.DeleteFunc(b.clients, func(o *bridgeClient) bool { return o == c })
+	b.mu.Unlock()
+	b.onChange()
+}
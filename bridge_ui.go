@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// stopBridge stops the TCP bridge if it is running.
+func (ui *AppUI) stopBridge() {
+	if ui.bridge != nil {
+		ui.bridge.Close()
+		ui.bridge = nil
+	}
+}
+
+// showBridgeDialog starts and stops the serial-to-TCP bridge and lists its
+// clients.
+func (ui *AppUI) showBridgeDialog() {
+	cfg := ui.settings.Bridge
+	portEntry := widget.NewEntry()
+	portEntry.SetText(strconv.Itoa(cfg.Port))
+	allInterfacesCheck := widget.NewCheck("Accept clients from other machines", nil)
+	allInterfacesCheck.SetChecked(cfg.AllInterfaces)
+	policySelect := widget.NewSelect(bridgeWritePolicies, nil)
+	policySelect.SetSelected(cfg.WritePolicy)
+	statusLabel := widget.NewLabel("")
+
+	var clients []BridgeClientInfo
+	selected := -1
+	list := widget.NewList(
+		func() int { return len(clients) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			if id >= len(clients) {
+				return
+			}
+			c := clients[id]
+			access := "read-only"
+			if c.CanWrite {
+				access = "read/write"
+			}
+			obj.(*widget.Label).SetText(fmt.Sprintf("%s  %s  since %s  in %d B, out %d B",
+				c.Addr, access, c.Connected.Format("15:04:05"), c.BytesIn, c.BytesOut))
+		},
+	)
+	list.OnSelected = func(id widget.ListItemID) { selected = id }
+
+	refresh := func() {
+		clients = nil
+		if ui.bridge != nil {
+			clients = ui.bridge.Clients()
+			statusLabel.SetText(fmt.Sprintf("Listening on %s, %d clients", ui.bridge.Addr(), len(clients)))
+		} else {
+			statusLabel.SetText("Stopped")
+		}
+		list.Refresh()
+	}
+
+	var startBtn *widget.Button
+	setRunning := func(running bool) {
+		if running {
+			startBtn.SetText("Stop")
+			portEntry.Disable()
+			allInterfacesCheck.Disable()
+			policySelect.Disable()
+		} else {
+			startBtn.SetText("Start")
+			portEntry.Enable()
+			allInterfacesCheck.Enable()
+			policySelect.Enable()
+		}
+		refresh()
+	}
+	startBtn = widget.NewButton("Start", func() {
+		if ui.bridge != nil {
+			ui.stopBridge()
+			setRunning(false)
+			return
+		}
+		port, err := strconv.Atoi(portEntry.Text)
+		if err != nil || port <= 0 || port > 65535 {
+			dialog.ShowError(fmt.Errorf("invalid port: %s", portEntry.Text), ui.window)
+			return
+		}
+		ui.settings.Bridge = BridgeSettings{Port: port, AllInterfaces: allInterfacesCheck.Checked, WritePolicy: policySelect.Selected}
+		ui.saveSettings()
+		bridge, err := StartBridge(ui.serial, port, allInterfacesCheck.Checked, policySelect.Selected, func() {
+			fyne.Do(refresh)
+		})
+		if err != nil {
+			dialog.ShowError(err, ui.window)
+			return
+		}
+		ui.bridge = bridge
+		setRunning(true)
+	})
+	kickBtn := widget.NewButton("Disconnect Client", func() {
+		if ui.bridge != nil && selected >= 0 && selected < len(clients) {
+			ui.bridge.Kick(clients[selected].Addr)
+		}
+	})
+	setRunning(ui.bridge != nil)
+
+	// Keep the byte counts current while the dialog is open.
+	ticker := time.NewTicker(time.Second)
+	stop := make(chan struct{})
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				fyne.Do(refresh)
+			}
+		}
+	}()
+
+	form := widget.NewForm(
+		widget.NewFormItem("TCP Port", portEntry),
+		widget.NewFormItem("", allInterfacesCheck),
+		widget.NewFormItem("Client Writes", policySelect),
+		widget.NewFormItem("Status", statusLabel),
+	)
+	listScroll := container.NewVScroll(list)
+	listScroll.SetMinSize(fyne.NewSize(480, 160))
+	content := container.NewBorder(form, container.NewHBox(startBtn, kickBtn), nil, nil, listScroll)
+
+	d := dialog.NewCustom("TCP Bridge", "Close", content, ui.window)
+	d.SetOnClosed(func() { close(stop) })
+	d.Show()
+}
@@ -23,7 +23,8 @@ func configDir() (string, error) {
 	return dir, nil
 }
 
-// LoadTemplates reads saved templates from disk. Returns empty slice if file doesn't exist.
+// LoadTemplates reads templates saved by versions before settings.json, for
+// migration. Returns empty slice if file doesn't exist.
 func LoadTemplates() ([]string, error) {
 	dir, err := configDir()
 	if err != nil {
@@ -45,22 +46,3 @@ func LoadTemplates() ([]string, error) {
 	}
 	return templates, nil
 }
-
-// SaveTemplates writes templates to disk.
-func SaveTemplates(templates []string) error {
-	dir, err := configDir()
-	if err != nil {
-		return err
-	}
-
-	data, err := json.MarshalIndent(templates, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal templates: %w", err)
-	}
-
-	path := filepath.Join(dir, templatesFileName)
-	if err := os.WriteFile(path, data, 0644); err != nil {
-		return fmt.Errorf("failed to write templates: %w", err)
-	}
-	return nil
-}
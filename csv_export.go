@@ -4,60 +4,40 @@ import (
 	"encoding/csv"
 	"fmt"
 	"os"
-	"strings"
-	"time"
 )
 
-// CSVExportOptions configures how serial data is exported to CSV.
-type CSVExportOptions struct {
-	FilePath          string
-	IncludeTimestamps bool
-	FilterByTime      bool
-	StartTime         time.Time
-	EndTime           time.Time
-	CustomHeader      []string // Custom header row; if nil, default or no header is used.
+// csvDelimiters maps the delimiter names offered in the export dialog to runes.
+var csvDelimiters = map[string]rune{"Comma": ',', "Semicolon": ';', "Tab": '\t'}
+
+var csvDelimiterNames = []string{"Comma", "Semicolon", "Tab"}
+
+// CSVOptions configures the CSV exporter.
+type CSVOptions struct {
+	Delimiter rune // field separator; ',' if zero
 }
 
-// ExportCSV writes the given serial lines to a CSV file based on the provided options.
-func ExportCSV(lines []SerialLine, opts CSVExportOptions) error {
-	f, err := os.Create(opts.FilePath)
+// csvExporter writes comma- (or otherwise) separated values.
+type csvExporter struct{}
+
+func (csvExporter) Format() string       { return FormatCSV }
+func (csvExporter) Extensions() []string { return []string{".csv", ".txt"} }
+
+func (csvExporter) Write(path string, table *ExportTable, opts ExportOptions) error {
+	f, err := os.Create(path)
 	if err != nil {
 		return fmt.Errorf("failed to create file: %w", err)
 	}
 	defer f.Close()
 
 	w := csv.NewWriter(f)
-
-	// Write header
-	if len(opts.CustomHeader) > 0 {
-		if err := w.Write(opts.CustomHeader); err != nil {
-			return fmt.Errorf("failed to write custom header: %w", err)
-		}
-	} else if opts.IncludeTimestamps {
-		if err := w.Write([]string{"Timestamp", "Data"}); err != nil {
-			return fmt.Errorf("failed to write header: %w", err)
-		}
-	} else {
-		if err := w.Write([]string{"Data"}); err != nil {
-			return fmt.Errorf("failed to write header: %w", err)
-		}
+	if opts.CSV.Delimiter != 0 {
+		w.Comma = opts.CSV.Delimiter
 	}
 
-	for _, line := range lines {
-		if opts.FilterByTime {
-			if line.Timestamp.Before(opts.StartTime) || line.Timestamp.After(opts.EndTime) {
-				continue
-			}
-		}
-
-		fields := strings.Split(line.Data, ",")
-		var record []string
-		if opts.IncludeTimestamps {
-			record = append([]string{line.Timestamp.Format("2006-01-02 15:04:05.000")}, fields...)
-		} else {
-			record = fields
-		}
-
+	if err := w.Write(table.Header); err != nil {
+		return fmt.Errorf("failed to write header: %w", err)
+	}
+	for _, record := range table.Rows {
 		if err := w.Write(record); err != nil {
 			return fmt.Errorf("failed to write record: %w", err)
 		}
@@ -0,0 +1,386 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Field is a single named value decoded from a frame.
+type Field struct {
+	Name  string
+	Value string
+}
+
+// Decoder turns a raw frame received from the port into named fields.
+type Decoder interface {
+	// Name identifies the decoder in the UI.
+	Name() string
+	// Delimiter is the byte that terminates a frame on the wire.
+	Delimiter() byte
+	// Decode parses one frame, without its delimiter.
+	Decode(frame string) ([]Field, error)
+}
+
+// decoders lists the built-in decoders in the order shown in the UI.
+var decoders = []Decoder{
+	csvDecoder{},
+	jsonDecoder{},
+	keyValueDecoder{},
+	nmeaDecoder{},
+	slipDecoder{},
+	cobsDecoder{},
+}
+
+// decoderNames returns the names of the built-in decoders.
+func decoderNames() []string {
+	names := make([]string, len(decoders))
+	for i, d := range decoders {
+		names[i] = d.Name()
+	}
+	return names
+}
+
+// decoderByName returns the built-in decoder with the given name, or the CSV
+// decoder if there is none.
+func decoderByName(name string) Decoder {
+	for _, d := range decoders {
+		if d.Name() == name {
+			return d
+		}
+	}
+	return csvDecoder{}
+}
+
+// isBinaryDecoder reports whether a decoder's frames are binary rather than text lines.
+func isBinaryDecoder(d Decoder) bool {
+	return d.Delimiter() != '\n'
+}
+
+// --- CSV ---
+
+// csvDecoder parses a line as one CSV record into positional fields. Quoted
+// fields may contain the delimiter. The zero value splits on commas and keeps
+// whitespace.
+type csvDecoder struct {
+	Comma rune // field delimiter; ',' if zero
+	Trim  bool // trim surrounding whitespace from each field
+}
+
+func (csvDecoder) Name() string    { return "CSV" }
+func (csvDecoder) Delimiter() byte { return '\n' }
+
+func (d csvDecoder) Decode(frame string) ([]Field, error) {
+	r := csv.NewReader(strings.NewReader(frame))
+	if d.Comma != 0 {
+		r.Comma = d.Comma
+	}
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	r.TrimLeadingSpace = d.Trim
+	values, err := r.Read()
+	if err == io.EOF {
+		values = []string{""} // a blank line is a single empty field
+	} else if err != nil {
+		return nil, fmt.Errorf("invalid CSV: %w", err)
+	}
+
+	fields := make([]Field, len(values))
+	for i, v := range values {
+		if d.Trim {
+			v = strings.TrimSpace(v)
+		}
+		fields[i] = Field{Name: fmt.Sprintf("Field%d", i+1), Value: v}
+	}
+	return fields, nil
+}
+
+// --- JSON ---
+
+// jsonDecoder parses a JSON object per line. Nested objects and arrays are
+// flattened into dotted names, e.g. "imu.accel.0".
+type jsonDecoder struct{}
+
+func (jsonDecoder) Name() string    { return "JSON" }
+func (jsonDecoder) Delimiter() byte { return '\n' }
+
+func (jsonDecoder) Decode(frame string) ([]Field, error) {
+	dec := json.NewDecoder(strings.NewReader(frame))
+	dec.UseNumber()
+
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if tok != json.Delim('{') {
+		return nil, fmt.Errorf("expected JSON object")
+	}
+
+	var fields []Field
+	if err := decodeJSONObject(dec, "", &fields); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	return fields, nil
+}
+
+// decodeJSONObject walks an object whose opening brace has been consumed,
+// appending leaf values in document order.
+func decodeJSONObject(dec *json.Decoder, prefix string, fields *[]Field) error {
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key, ok := tok.(string)
+		if !ok {
+			return fmt.Errorf("expected object key")
+		}
+		if err := decodeJSONValue(dec, prefix+key, fields); err != nil {
+			return err
+		}
+	}
+	_, err := dec.Token() // closing brace
+	return err
+}
+
+func decodeJSONValue(dec *json.Decoder, name string, fields *[]Field) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	switch v := tok.(type) {
+	case json.Delim:
+		if v == '{' {
+			return decodeJSONObject(dec, name+".", fields)
+		}
+		for i := 0; dec.More(); i++ {
+			if err := decodeJSONValue(dec, fmt.Sprintf("%s.%d", name, i), fields); err != nil {
+				return err
+			}
+		}
+		_, err := dec.Token() // closing bracket
+		return err
+	case nil:
+		*fields = append(*fields, Field{Name: name})
+	case string:
+		*fields = append(*fields, Field{Name: name, Value: v})
+	default:
+		*fields = append(*fields, Field{Name: name, Value: fmt.Sprint(v)})
+	}
+	return nil
+}
+
+// --- key=value ---
+
+// keyValueDecoder parses pairs such as "temp=21.5 hum=40" or "temp:21.5, hum:40".
+type keyValueDecoder struct{}
+
+func (keyValueDecoder) Name() string    { return "key=value" }
+func (keyValueDecoder) Delimiter() byte { return '\n' }
+
+func (keyValueDecoder) Decode(frame string) ([]Field, error) {
+	tokens := strings.FieldsFunc(frame, func(r rune) bool {
+		return r == ' ' || r == '\t' || r == ',' || r == ';'
+	})
+
+	var fields []Field
+	for _, tok := range tokens {
+		idx := strings.IndexAny(tok, "=:")
+		if idx <= 0 {
+			continue
+		}
+		fields = append(fields, Field{Name: tok[:idx], Value: tok[idx+1:]})
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("no key=value pairs found")
+	}
+	return fields, nil
+}
+
+// --- NMEA 0183 ---
+
+// nmeaFieldNames names the fields of common sentences, keyed by sentence
+// type without the talker ID.
+var nmeaFieldNames = map[string][]string{
+	"GGA": {"Time", "Latitude", "NS", "Longitude", "EW", "Quality", "Satellites", "HDOP", "Altitude", "AltitudeUnit", "GeoidSeparation", "GeoidUnit", "DGPSAge", "DGPSStation"},
+	"RMC": {"Time", "Status", "Latitude", "NS", "Longitude", "EW", "SpeedKnots", "Course", "Date", "MagneticVariation", "MagneticVariationDir", "Mode"},
+	"GLL": {"Latitude", "NS", "Longitude", "EW", "Time", "Status", "Mode"},
+	"VTG": {"TrueCourse", "T", "MagneticCourse", "M", "SpeedKnots", "N", "SpeedKmh", "K", "Mode"},
+}
+
+// nmeaDecoder parses NMEA 0183 sentences such as "$GPGGA,...*47", verifying
+// the checksum when present.
+type nmeaDecoder struct{}
+
+func (nmeaDecoder) Name() string    { return "NMEA" }
+func (nmeaDecoder) Delimiter() byte { return '\n' }
+
+func (nmeaDecoder) Decode(frame string) ([]Field, error) {
+	frame = strings.TrimSpace(frame)
+	if len(frame) < 2 || (frame[0] != '$' && frame[0] != '!') {
+		return nil, fmt.Errorf("not an NMEA sentence")
+	}
+	body := frame[1:]
+
+	if idx := strings.LastIndexByte(body, '*'); idx >= 0 {
+		want, err := strconv.ParseUint(body[idx+1:], 16, 8)
+		if err != nil {
+			return nil, fmt.Errorf("invalid NMEA checksum %q", body[idx+1:])
+		}
+		body = body[:idx]
+		var sum byte
+		for i := 0; i < len(body); i++ {
+			sum ^= body[i]
+		}
+		if sum != byte(want) {
+			return nil, fmt.Errorf("NMEA checksum mismatch: got %02X, want %02X", sum, want)
+		}
+	}
+
+	parts := strings.Split(body, ",")
+	fields := []Field{{Name: "Sentence", Value: parts[0]}}
+	var names []string
+	if len(parts[0]) >= 3 {
+		names = nmeaFieldNames[parts[0][len(parts[0])-3:]]
+	}
+	for i, v := range parts[1:] {
+		name := fmt.Sprintf("Field%d", i+1)
+		if i < len(names) {
+			name = names[i]
+		}
+		fields = append(fields, Field{Name: name, Value: v})
+	}
+	return fields, nil
+}
+
+// --- SLIP (RFC 1055) ---
+
+const (
+	slipEnd    = 0xC0
+	slipEsc    = 0xDB
+	slipEscEnd = 0xDC
+	slipEscEsc = 0xDD
+)
+
+// slipDecoder unescapes SLIP frames delimited by END bytes.
+type slipDecoder struct{}
+
+func (slipDecoder) Name() string    { return "SLIP" }
+func (slipDecoder) Delimiter() byte { return slipEnd }
+
+func (slipDecoder) Decode(frame string) ([]Field, error) {
+	var out []byte
+	for i := 0; i < len(frame); i++ {
+		b := frame[i]
+		if b != slipEsc {
+			out = append(out, b)
+			continue
+		}
+		i++
+		if i >= len(frame) {
+			return nil, fmt.Errorf("SLIP frame ends with escape byte")
+		}
+		switch frame[i] {
+		case slipEscEnd:
+			out = append(out, slipEnd)
+		case slipEscEsc:
+			out = append(out, slipEsc)
+		default:
+			return nil, fmt.Errorf("invalid SLIP escape 0x%02X", frame[i])
+		}
+	}
+	return binaryFields(out), nil
+}
+
+// --- COBS ---
+
+// cobsDecoder decodes Consistent Overhead Byte Stuffing frames delimited by zero bytes.
+type cobsDecoder struct{}
+
+func (cobsDecoder) Name() string    { return "COBS" }
+func (cobsDecoder) Delimiter() byte { return 0x00 }
+
+func (cobsDecoder) Decode(frame string) ([]Field, error) {
+	var out []byte
+	for i := 0; i < len(frame); {
+		code := int(frame[i])
+		if code == 0 {
+			return nil, fmt.Errorf("unexpected zero in COBS frame")
+		}
+		if i+code > len(frame) {
+			return nil, fmt.Errorf("truncated COBS frame")
+		}
+		out = append(out, frame[i+1:i+code]...)
+		i += code
+		if code < 0xFF && i < len(frame) {
+			out = append(out, 0)
+		}
+	}
+	return binaryFields(out), nil
+}
+
+// binaryFields describes a decoded binary payload.
+func binaryFields(payload []byte) []Field {
+	return []Field{
+		{Name: "Length", Value: strconv.Itoa(len(payload))},
+		{Name: "Payload", Value: strings.ToUpper(hex.EncodeToString(payload))},
+	}
+}
+
+// formatHex renders raw frame bytes as space-separated hex for display.
+func formatHex(data string) string {
+	return fmt.Sprintf("% X", []byte(data))
+}
+
+// DecodedLine pairs a received line with the fields decoded from it.
+type DecodedLine struct {
+	Line   SerialLine // as received, in the device's encoding
+	Text   string     // what the decoder saw: Line.Data converted to UTF-8 for text decoders
+	Fields []Field
+}
+
+// decodeLines decodes every line that the decoder accepts and returns them with
+// the union of field names in order of first appearance. Lines that fail to
+// decode, such as boot banners, are skipped. Text decoders see each line
+// converted from encoding; the returned lines keep their received bytes.
+func decodeLines(lines []SerialLine, decoder Decoder, encoding string) ([]DecodedLine, []string) {
+	var decoded []DecodedLine
+	var columns []string
+	seen := make(map[string]bool)
+	for _, line := range lines {
+		text := line.Data
+		if !isBinaryDecoder(decoder) {
+			text = decodeText(line.Data, encoding)
+		}
+		fields, err := decoder.Decode(text)
+		if err != nil {
+			continue
+		}
+		for _, f := range fields {
+			if !seen[f.Name] {
+				seen[f.Name] = true
+				columns = append(columns, f.Name)
+			}
+		}
+		decoded = append(decoded, DecodedLine{Line: line, Text: text, Fields: fields})
+	}
+	return decoded, columns
+}
+
+// fieldValues lays out decoded fields in column order, leaving gaps empty.
+func fieldValues(fields []Field, columns []string) []string {
+	values := make([]string, len(columns))
+	for _, f := range fields {
+		for i, c := range columns {
+			if c == f.Name {
+				values[i] = f.Value
+				break
+			}
+		}
+	}
+	return values
+}
@@ -0,0 +1,396 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DeviceScript describes an emulated device: what it prints at boot, how it
+// answers commands, what it prints periodically and how it misbehaves.
+// Scripts are JSON files; with a fixed seed the output is reproducible.
+type DeviceScript struct {
+	Seed      int64             `json:"seed"`      // random seed; 0 picks one at start
+	Banner    []string          `json:"banner"`    // lines printed at boot
+	Echo      bool              `json:"echo"`      // echo each command, prefixed with "> "
+	Responses map[string]string `json:"responses"` // reply by command, matched exactly after trimming
+	Unknown   string            `json:"unknown"`   // reply to other commands, where {cmd} is the command; none if empty
+	Emitters  []Emitter         `json:"emitters"`
+	Faults    Faults            `json:"faults"`
+}
+
+// Emitter prints a line of signal values at a fixed interval.
+type Emitter struct {
+	IntervalMS int      `json:"intervalMs"`
+	Format     string   `json:"format"` // e.g. "T={t} V={0}"; {t} is milliseconds, {n} the line count and {i} signal i. Default: {t} then the values, comma separated
+	Signals    []Signal `json:"signals"`
+	BurstEvery int      `json:"burstEvery"` // every this many lines, print a burst; never if 0
+	BurstSize  int      `json:"burstSize"`  // lines per burst, printed back to back
+}
+
+// Signal waveform names.
+const (
+	WaveSine     = "sine"
+	WaveRamp     = "ramp"
+	WaveSquare   = "square"
+	WaveNoise    = "noise"
+	WaveConstant = "constant"
+)
+
+var waveNames = []string{WaveSine, WaveRamp, WaveSquare, WaveNoise, WaveConstant}
+
+// Signal is one generated value. Periodic waves swing by Amplitude around
+// Offset; noise is Gaussian with Amplitude as its standard deviation.
+type Signal struct {
+	Wave      string  `json:"wave"`
+	Offset    float64 `json:"offset"`
+	Amplitude float64 `json:"amplitude"`
+	PeriodMS  int     `json:"periodMs"`
+	Phase     float64 `json:"phase"`    // fraction of a period, 0 to 1
+	Noise     float64 `json:"noise"`    // standard deviation of jitter added to the wave
+	Decimals  int     `json:"decimals"` // digits after the decimal point
+}
+
+// Faults inject the errors of a flaky device or cable.
+type Faults struct {
+	DropRate          float64 `json:"dropRate"`          // chance, 0 to 1, that each output byte is lost
+	GarbageRate       float64 `json:"garbageRate"`       // chance, 0 to 1, that a line is replaced by random bytes
+	DisconnectAfterMS int     `json:"disconnectAfterMs"` // unplug after this long; never if 0
+}
+
+// LoadDeviceScript reads a script from a JSON file.
+func LoadDeviceScript(path string) (DeviceScript, error) {
+	var s DeviceScript
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return s, fmt.Errorf("failed to read device script: %w", err)
+	}
+	if err := json.Unmarshal(data, &s); err != nil {
+		return s, fmt.Errorf("failed to parse device script: %w", err)
+	}
+	if err := s.validate(); err != nil {
+		return s, fmt.Errorf("invalid device script: %w", err)
+	}
+	return s, nil
+}
+
+// validate checks the script describes a runnable device.
+func (s DeviceScript) validate() error {
+	for i, em := range s.Emitters {
+		if em.IntervalMS <= 0 {
+			return fmt.Errorf("emitter %d: interval must be positive", i+1)
+		}
+		if em.BurstEvery < 0 || em.BurstSize < 0 {
+			return fmt.Errorf("emitter %d: burst settings must not be negative", i+1)
+		}
+		for j, sig := range em.Signals {
+			if !slices.Contains(waveNames, sig.Wave) {
+				return fmt.Errorf("emitter %d, signal %d: unknown wave %q", i+1, j+1, sig.Wave)
+			}
+			periodic := sig.Wave == WaveSine || sig.Wave == WaveRamp || sig.Wave == WaveSquare
+			if periodic && sig.PeriodMS <= 0 {
+				return fmt.Errorf("emitter %d, signal %d: period must be positive", i+1, j+1)
+			}
+			if sig.Decimals < 0 || sig.Decimals > 10 {
+				return fmt.Errorf("emitter %d, signal %d: decimals must be between 0 and 10", i+1, j+1)
+			}
+		}
+	}
+	f := s.Faults
+	if f.DropRate < 0 || f.DropRate > 1 || f.GarbageRate < 0 || f.GarbageRate > 1 {
+		return fmt.Errorf("fault rates must be between 0 and 1")
+	}
+	if f.DisconnectAfterMS < 0 {
+		return fmt.Errorf("disconnect time must not be negative")
+	}
+	return nil
+}
+
+// emulator plays a DeviceScript on a virtual clock: time is the offset from
+// boot, so the same seed always produces the same bytes at the same offsets.
+// The output loop (banner, nextDue, fire) and the reply loop (reply) use
+// separate state and random sources, so they need no locking and commands
+// never change what the emitters print.
+type emulator struct {
+	script   DeviceScript
+	rng      *rand.Rand      // for the banner and emitters
+	replyRng *rand.Rand      // for replies
+	next     []time.Duration // next due time of each emitter
+	counts   []int           // lines printed by each emitter
+}
+
+func newEmulator(script DeviceScript) *emulator {
+	seed := script.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	e := &emulator{
+		script:   script,
+		rng:      rand.New(rand.NewSource(seed)),
+		replyRng: rand.New(rand.NewSource(seed + 1)),
+		next:     make([]time.Duration, len(script.Emitters)),
+		counts:   make([]int, len(script.Emitters)),
+	}
+	for i, em := range script.Emitters {
+		e.next[i] = time.Duration(em.IntervalMS) * time.Millisecond
+	}
+	return e
+}
+
+// banner returns the boot output.
+func (e *emulator) banner() []byte {
+	var out []byte
+	for _, line := range e.script.Banner {
+		out = append(out, e.corrupt(e.rng, line)...)
+	}
+	return out
+}
+
+// nextDue returns when the next emitter fires, or false if none ever will.
+func (e *emulator) nextDue() (time.Duration, bool) {
+	if len(e.next) == 0 {
+		return 0, false
+	}
+	due := e.next[0]
+	for _, t := range e.next[1:] {
+		due = min(due, t)
+	}
+	return due, true
+}
+
+// fire returns the output of every emitter due at or before at, and
+// schedules each one's next line.
+func (e *emulator) fire(at time.Duration) []byte {
+	var out []byte
+	for i, em := range e.script.Emitters {
+		if e.next[i] > at {
+			continue
+		}
+		lines := 1
+		if em.BurstEvery > 0 && em.BurstSize > 0 && (e.counts[i]+1)%em.BurstEvery == 0 {
+			lines = em.BurstSize
+		}
+		for range lines {
+			e.counts[i]++
+			out = append(out, e.corrupt(e.rng, e.format(em, e.next[i], e.counts[i]))...)
+		}
+		e.next[i] += time.Duration(em.IntervalMS) * time.Millisecond
+	}
+	return out
+}
+
+// reply returns the device's answer to a command line.
+func (e *emulator) reply(cmd string) []byte {
+	cmd = strings.TrimSpace(cmd)
+	var out []byte
+	if e.script.Echo {
+		out = append(out, e.corrupt(e.replyRng, "> "+cmd)...)
+	}
+	if r, ok := e.script.Responses[cmd]; ok {
+		out = append(out, e.corrupt(e.replyRng, r)...)
+	} else if e.script.Unknown != "" {
+		out = append(out, e.corrupt(e.replyRng, strings.ReplaceAll(e.script.Unknown, "{cmd}", cmd))...)
+	}
+	return out
+}
+
+// format renders emitter em's n-th line, due at offset at.
+func (e *emulator) format(em Emitter, at time.Duration, n int) string {
+	values := make([]string, len(em.Signals))
+	for i, sig := range em.Signals {
+		values[i] = strconv.FormatFloat(e.sample(sig, at), 'f', sig.Decimals, 64)
+	}
+	ms := strconv.FormatInt(at.Milliseconds(), 10)
+	if em.Format == "" {
+		return strings.Join(append([]string{ms}, values...), ",")
+	}
+	pairs := []string{"{t}", ms, "{n}", strconv.Itoa(n)}
+	for i, v := range values {
+		pairs = append(pairs, "{"+strconv.Itoa(i)+"}", v)
+	}
+	return strings.NewReplacer(pairs...).Replace(em.Format)
+}
+
+// sample returns a signal's value at offset at.
+func (e *emulator) sample(sig Signal, at time.Duration) float64 {
+	var pos float64 // position in the current period, 0 to 1
+	if sig.PeriodMS > 0 {
+		_, pos = math.Modf(float64(at.Milliseconds())/float64(sig.PeriodMS) + sig.Phase)
+	}
+	v := sig.Offset
+	switch sig.Wave {
+	case WaveSine:
+		v += sig.Amplitude * math.Sin(2*math.Pi*pos)
+	case WaveRamp:
+		v += sig.Amplitude * (2*pos - 1)
+	case WaveSquare:
+		if pos < 0.5 {
+			v += sig.Amplitude
+		} else {
+			v -= sig.Amplitude
+		}
+	case WaveNoise:
+		v += sig.Amplitude * e.rng.NormFloat64()
+	}
+	if sig.Noise > 0 {
+		v += sig.Noise * e.rng.NormFloat64()
+	}
+	return v
+}
+
+// corrupt terminates a line and applies the script's faults to it, drawing
+// from rng.
+func (e *emulator) corrupt(rng *rand.Rand, line string) []byte {
+	f := e.script.Faults
+	if f.GarbageRate > 0 && rng.Float64() < f.GarbageRate {
+		b := make([]byte, 4+rng.Intn(20))
+		for i := range b {
+			b[i] = byte(rng.Intn(256))
+			if b[i] == '\n' {
+				b[i] = '?'
+			}
+		}
+		line = string(b)
+	}
+	out := []byte(line + "\n")
+	if f.DropRate > 0 {
+		kept := out[:0]
+		for _, c := range out {
+			if rng.Float64() >= f.DropRate {
+				kept = append(kept, c)
+			}
+		}
+		out = kept
+	}
+	return out
+}
+
+// unplugAt returns when the device disconnects itself, or false if never.
+func (e *emulator) unplugAt() (time.Duration, bool) {
+	ms := e.script.Faults.DisconnectAfterMS
+	return time.Duration(ms) * time.Millisecond, ms > 0
+}
+
+// run plays the script to w in real time until stop is closed, a write fails
+// or the script unplugs the device. It reports whether the device unplugged.
+func (e *emulator) run(w io.Writer, mu *sync.Mutex, stop <-chan struct{}) bool {
+	write := func(b []byte) error {
+		mu.Lock()
+		defer mu.Unlock()
+		_, err := w.Write(b)
+		return err
+	}
+	boot := time.Now()
+	if write(e.banner()) != nil {
+		return false
+	}
+	unplug, unplugs := e.unplugAt()
+	for {
+		at, ok := e.nextDue()
+		unplugging := unplugs && (!ok || unplug <= at)
+		if unplugging {
+			at = unplug
+		} else if !ok {
+			<-stop
+			return false
+		}
+		timer := time.NewTimer(time.Until(boot.Add(at)))
+		select {
+		case <-stop:
+			timer.Stop()
+			return false
+		case <-timer.C:
+		}
+		if unplugging {
+			return true
+		}
+		if write(e.fire(at)) != nil {
+			return false
+		}
+	}
+}
+
+// serve answers commands read line by line from r until r fails.
+func (e *emulator) serve(r io.Reader, w io.Writer, mu *sync.Mutex) {
+	buf := make([]byte, 256)
+	var partial []byte
+	for {
+		n, err := r.Read(buf)
+		partial = append(partial, buf[:n]...)
+		for {
+			idx := bytes.IndexByte(partial, '\n')
+			if idx < 0 {
+				break
+			}
+			out := e.reply(string(partial[:idx]))
+			partial = partial[idx+1:]
+			mu.Lock()
+			_, werr := w.Write(out)
+			mu.Unlock()
+			if werr != nil {
+				return
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// RenderScript plays a script for the given duration on its virtual clock
+// and splits the output into lines the way the serial reader does, stamped
+// from start. Without a serial port or real time involved, the same seeded
+// script always produces the same lines, so the display, exporters and sinks
+// can be exercised deterministically.
+func RenderScript(script DeviceScript, duration time.Duration, start time.Time) []SerialLine {
+	e := newEmulator(script)
+	// Like run, stop before lines due at the moment of unplugging.
+	end := duration + 1
+	if unplug, ok := e.unplugAt(); ok {
+		end = min(end, unplug)
+	}
+
+	var lines []SerialLine
+	var partial []byte
+	var partialAt time.Duration
+	emit := func(out []byte, at time.Duration) {
+		if len(partial) == 0 {
+			partialAt = at
+		}
+		partial = append(partial, out...)
+		for {
+			idx := bytes.IndexByte(partial, '\n')
+			if idx < 0 {
+				return
+			}
+			data := strings.TrimSuffix(string(partial[:idx]), "\r")
+			lines = append(lines, SerialLine{
+				Timestamp: start.Add(at),
+				Data:      data,
+				FirstByte: start.Add(partialAt),
+				LastByte:  start.Add(at),
+			})
+			partial = partial[idx+1:]
+			partialAt = at
+		}
+	}
+
+	emit(e.banner(), 0)
+	for {
+		at, ok := e.nextDue()
+		if !ok || at >= end {
+			break
+		}
+		emit(e.fire(at), at)
+	}
+	return lines
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testScript exercises every random feature: noise, garbage and dropped bytes.
+var testScript = DeviceScript{
+	Seed:      42,
+	Banner:    []string{"boot v1"},
+	Echo:      true,
+	Responses: map[string]string{"ping": "pong"},
+	Emitters: []Emitter{
+		{IntervalMS: 100, Format: "T={t} A={0} B={1}", Signals: []Signal{
+			{Wave: WaveSine, Offset: 20, Amplitude: 5, PeriodMS: 1000, Noise: 0.5, Decimals: 2},
+			{Wave: WaveNoise, Amplitude: 1, Decimals: 3},
+		}},
+		{IntervalMS: 250, Signals: []Signal{{Wave: WaveRamp, Amplitude: 10, PeriodMS: 1000}}, BurstEvery: 3, BurstSize: 2},
+	},
+	Faults: Faults{DropRate: 0.01, GarbageRate: 0.05},
+}
+
+func lineData(lines []SerialLine) []string {
+	data := make([]string, len(lines))
+	for i, l := range lines {
+		data[i] = l.Data
+	}
+	return data
+}
+
+func TestRenderScriptIsRepeatable(t *testing.T) {
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	first := RenderScript(testScript, 10*time.Second, start)
+	second := RenderScript(testScript, 10*time.Second, start)
+	if len(first) < 100 {
+		t.Fatalf("rendered only %d lines", len(first))
+	}
+	if !slices.EqualFunc(first, second, func(a, b SerialLine) bool {
+		return a.Data == b.Data && a.Timestamp.Equal(b.Timestamp) && a.FirstByte.Equal(b.FirstByte)
+	}) {
+		t.Fatal("the same seeded script rendered different lines")
+	}
+	if last := first[len(first)-1].Timestamp; last.After(start.Add(10 * time.Second)) {
+		t.Errorf("last line at %s, after the rendered span", last)
+	}
+}
+
+func TestRenderScriptGolden(t *testing.T) {
+	script := DeviceScript{
+		Seed:   1,
+		Banner: []string{"ready"},
+		Emitters: []Emitter{{IntervalMS: 250, Format: "{n}: {0}", Signals: []Signal{
+			{Wave: WaveSquare, Offset: 1, Amplitude: 1, PeriodMS: 1000},
+		}}},
+	}
+	got := lineData(RenderScript(script, time.Second, time.Now()))
+	want := []string{"ready", "1: 2", "2: 0", "3: 0", "4: 2"}
+	if !slices.Equal(got, want) {
+		t.Errorf("rendered %q, want %q", got, want)
+	}
+}
+
+func TestEmulatorRepliesDontChangeEmitters(t *testing.T) {
+	// Replies with faults draw random numbers too; they must not shift the
+	// emitters' sequence.
+	want := lineData(RenderScript(testScript, 2*time.Second, time.Now()))
+
+	e := newEmulator(testScript)
+	out := string(e.banner())
+	for {
+		at, ok := e.nextDue()
+		if !ok || at > 2*time.Second {
+			break
+		}
+		e.reply("ping")
+		out += string(e.fire(at))
+	}
+	got := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	if !slices.Equal(got, want) {
+		t.Error("replies changed the emitters' output")
+	}
+}
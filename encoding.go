@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+)
+
+// Text encodings a device may print in.
+const (
+	EncodingUTF8        = "UTF-8"
+	EncodingASCII       = "ASCII"
+	EncodingLatin1      = "ISO-8859-1"
+	EncodingWindows1252 = "Windows-1252"
+	EncodingCP437       = "CP437"
+	EncodingShiftJIS    = "Shift-JIS"
+)
+
+var encodingNames = []string{EncodingUTF8, EncodingASCII, EncodingLatin1, EncodingWindows1252, EncodingCP437, EncodingShiftJIS}
+
+// singleByteEncodings maps each byte to one character.
+var singleByteEncodings = map[string]*charmap.Charmap{
+	EncodingLatin1:      charmap.ISO8859_1,
+	EncodingWindows1252: charmap.Windows1252,
+	EncodingCP437:       charmap.CodePage437,
+}
+
+// decodeText converts a line's raw bytes from the given encoding to UTF-8.
+// Bytes that aren't valid in the encoding are shown as \xNN escapes rather
+// than replacement characters, so what the device sent stays visible. An
+// empty encoding returns the data unchanged.
+func decodeText(data, encoding string) string {
+	var b strings.Builder
+	escape := func(c byte) { fmt.Fprintf(&b, `\x%02X`, c) }
+
+	switch encoding {
+	case "":
+		return data
+	case EncodingASCII:
+		for i := 0; i < len(data); i++ {
+			if data[i] < utf8.RuneSelf {
+				b.WriteByte(data[i])
+			} else {
+				escape(data[i])
+			}
+		}
+	case EncodingShiftJIS:
+		decodeShiftJIS(&b, data, escape)
+	case EncodingLatin1, EncodingWindows1252, EncodingCP437:
+		cm := singleByteEncodings[encoding]
+		for i := 0; i < len(data); i++ {
+			if data[i] < utf8.RuneSelf {
+				b.WriteByte(data[i])
+			} else if r := cm.DecodeByte(data[i]); r != utf8.RuneError {
+				b.WriteRune(r)
+			} else {
+				escape(data[i])
+			}
+		}
+	default: // UTF-8
+		if utf8.ValidString(data) {
+			return data
+		}
+		for i := 0; i < len(data); {
+			r, size := utf8.DecodeRuneInString(data[i:])
+			if r == utf8.RuneError && size == 1 {
+				escape(data[i])
+			} else {
+				b.WriteString(data[i : i+size])
+			}
+			i += size
+		}
+	}
+	return b.String()
+}
+
+// decodeShiftJIS writes data decoded as Shift-JIS to b: ASCII, half-width
+// katakana, and two-byte characters, escaping lead bytes that don't start a
+// valid pair.
+func decodeShiftJIS(b *strings.Builder, data string, escape func(byte)) {
+	dec := japanese.ShiftJIS.NewDecoder()
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case c < utf8.RuneSelf:
+			b.WriteByte(c)
+			continue
+		case c >= 0xA1 && c <= 0xDF:
+			b.WriteRune(0xFF61 + rune(c-0xA1))
+			continue
+		case (c >= 0x81 && c <= 0x9F || c >= 0xE0 && c <= 0xFC) && i+1 < len(data) && shiftJISTrail(data[i+1]):
+			dec.Reset()
+			if s, err := dec.String(data[i : i+2]); err == nil && !strings.ContainsRune(s, utf8.RuneError) {
+				b.WriteString(s)
+				i++
+				continue
+			}
+		}
+		escape(c)
+	}
+}
+
+// shiftJISTrail reports whether c can be the second byte of a two-byte
+// Shift-JIS character.
+func shiftJISTrail(c byte) bool {
+	return c >= 0x40 && c <= 0x7E || c >= 0x80 && c <= 0xFC
+}
@@ -0,0 +1,422 @@
+package main
+
+import (
+	"cmp"
+	"math"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Export formats offered in the export dialog.
+const (
+	FormatCSV     = "CSV"
+	FormatJSONL   = "JSON Lines"
+	FormatXLSX    = "Excel (XLSX)"
+	FormatParquet = "Parquet"
+)
+
+// Policies for rows whose field count differs from the header.
+const (
+	MismatchPad  = "Pad"  // pad short rows with empty cells; extra fields are kept
+	MismatchSkip = "Skip" // leave the row out
+	MismatchKeep = "Keep" // write the row's fields as received
+)
+
+var mismatchPolicies = []string{MismatchPad, MismatchSkip, MismatchKeep}
+
+// ColumnType is the inferred type of an exported column.
+type ColumnType int
+
+const (
+	ColumnString ColumnType = iota
+	ColumnInt
+	ColumnFloat
+)
+
+// Exporter writes a prepared table of serial data to a file in one format.
+type Exporter interface {
+	// Format identifies the exporter in the UI.
+	Format() string
+	// Extensions lists recognised file extensions; the first is the default.
+	Extensions() []string
+	// Write creates the file at path and writes the table to it.
+	Write(path string, table *ExportTable, opts ExportOptions) error
+}
+
+// exporters lists the available exporters in the order shown in the UI.
+var exporters = []Exporter{
+	csvExporter{},
+	jsonlExporter{},
+	xlsxExporter{},
+	parquetExporter{},
+}
+
+// exportFormats returns the names of the available export formats.
+func exportFormats() []string {
+	names := make([]string, len(exporters))
+	for i, e := range exporters {
+		names[i] = e.Format()
+	}
+	return names
+}
+
+// exporterByFormat returns the exporter for a format name, or CSV if unknown.
+func exporterByFormat(format string) Exporter {
+	for _, e := range exporters {
+		if e.Format() == format {
+			return e
+		}
+	}
+	return csvExporter{}
+}
+
+// exporterForPath picks an exporter from a file's extension.
+func exporterForPath(path string) (Exporter, bool) {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, e := range exporters {
+		for _, x := range e.Extensions() {
+			if x == ext {
+				return e, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// ExportOptions configures how serial data is exported.
+type ExportOptions struct {
+	FilePath          string
+	Format            string // one of exportFormats
+	IncludeTimestamps bool
+	IncludeFirstByte  bool // with timestamps, add the arrival of each line's first byte
+	FilterByTime      bool
+	StartTime         time.Time
+	EndTime           time.Time
+	FilterBySeq       bool              // keep lines from StartSeq to EndSeq, such as a selection
+	StartSeq          uint64            // inclusive
+	EndSeq            uint64            // inclusive
+	TimestampFormat   string            // one of timestampFormats; Local if empty
+	Connects          []time.Time       // connection start times, oldest first, for TimestampSinceConnect
+	CustomHeader      []string          // names for the data columns; decoded field names if nil
+	CustomTypes       []string          // template column types aligned with CustomHeader; auto if empty
+	Decoder           Decoder           // Splits each line into fields; CSV if nil.
+	Columns           []string          // Decoded fields to write, in order; all if nil.
+	Mismatch          string            // one of mismatchPolicies; Pad if empty
+	InferTypes        bool              // detect integer and float columns for typed formats
+	Bookmarks         map[uint64]string // notes by line Seq; adds a Bookmark column when non-nil
+	Encoding          string            // text encoding of the lines, one of encodingNames; raw bytes if empty
+
+	// Per-format options
+	CSV     CSVOptions
+	JSONL   JSONLOptions
+	XLSX    XLSXOptions
+	Parquet ParquetOptions
+}
+
+// ExportTable is the format-independent result of filtering and decoding lines.
+type ExportTable struct {
+	Header []string
+	Rows   [][]string   // one record per exported line, timestamps and bookmark first if included
+	Lines  []SerialLine // source line for each row
+	Types  []ColumnType // per-column type when inferred, nil otherwise
+}
+
+// ExportResult reports what an export wrote.
+type ExportResult struct {
+	Rows       int      // rows written
+	Mismatched int      // rows whose field count differed from the header
+	Skipped    int      // mismatched rows left out
+	Undecoded  int      // lines the decoder couldn't split into fields, left out
+	Untyped    []string // template columns written as text because a value didn't fit their type
+}
+
+// buildExportTable filters lines by time or Seq, decodes them and lays the fields
+// out in columns under the chosen header, applying the mismatch policy to rows
+// with the wrong number of fields.
+func buildExportTable(lines []SerialLine, opts ExportOptions) (*ExportTable, ExportResult) {
+	decoder := opts.Decoder
+	if decoder == nil {
+		decoder = csvDecoder{}
+	}
+
+	var filtered []SerialLine
+	for _, line := range lines {
+		if opts.FilterByTime {
+			if line.Timestamp.Before(opts.StartTime) || line.Timestamp.After(opts.EndTime) {
+				continue
+			}
+		}
+		if opts.FilterBySeq && (line.Seq < opts.StartSeq || line.Seq > opts.EndSeq) {
+			continue
+		}
+		filtered = append(filtered, line)
+	}
+	rows, columns := decodeLines(filtered, decoder, opts.Encoding)
+	selected := len(opts.Columns) > 0
+	if selected {
+		columns = opts.Columns
+	}
+
+	// Custom headers name the data columns only; the timestamp and bookmark
+	// columns are always added in front.
+	header := columns
+	if len(opts.CustomHeader) > 0 {
+		header = opts.CustomHeader
+	}
+	table := &ExportTable{Header: append(opts.prefixColumns(), header...)}
+	prefix := len(opts.prefixColumns())
+	expected := len(header)
+
+	result := ExportResult{Undecoded: len(filtered) - len(rows)}
+	for _, row := range rows {
+		fields := row.Fields
+		if selected {
+			// Only the chosen columns count towards the row's fields.
+			fields = nil
+			for _, f := range row.Fields {
+				if slices.Contains(columns, f.Name) {
+					fields = append(fields, f)
+				}
+			}
+		}
+
+		var record []string
+		if len(fields) == expected {
+			record = padRecord(fieldValues(row.Fields, columns), expected)
+		} else {
+			result.Mismatched++
+			switch opts.Mismatch {
+			case MismatchSkip:
+				result.Skipped++
+				continue
+			case MismatchKeep:
+				for _, f := range fields {
+					record = append(record, f.Value)
+				}
+			default:
+				record = padRecord(fieldValues(row.Fields, columns), expected)
+			}
+		}
+		if prefix > 0 {
+			record = append(make([]string, prefix), record...) // filled in below
+		}
+		table.Rows = append(table.Rows, record)
+		table.Lines = append(table.Lines, row.Line)
+	}
+	result.Rows = len(table.Rows)
+
+	if opts.IncludeTimestamps {
+		var first time.Time
+		for _, line := range table.Lines {
+			if first.IsZero() || line.Timestamp.Before(first) {
+				first = line.Timestamp
+			}
+		}
+		// Deltas are taken from the line that arrived before, even when the
+		// rows are in the table's sort order.
+		prev := make([]*SerialLine, len(table.Lines))
+		arrival := make([]int, len(table.Lines))
+		for i := range arrival {
+			arrival[i] = i
+		}
+		slices.SortStableFunc(arrival, func(a, b int) int {
+			return cmp.Compare(table.Lines[a].Seq, table.Lines[b].Seq)
+		})
+		for k := 1; k < len(arrival); k++ {
+			prev[arrival[k]] = &table.Lines[arrival[k-1]]
+		}
+
+		for i, line := range table.Lines {
+			origin := first
+			if opts.TimestampFormat == TimestampSinceConnect {
+				// Lines from an earlier connection count from its own start.
+				if start := connectOrigin(opts.Connects, line.Timestamp); !start.IsZero() {
+					origin = start
+				}
+			}
+			var prevTime, prevFirst time.Time
+			if prev[i] != nil {
+				prevTime, prevFirst = prev[i].Timestamp, firstByteTime(*prev[i])
+			}
+			table.Rows[i][0] = formatExportTimestamp(line.Timestamp, opts.TimestampFormat, origin, prevTime)
+			if opts.IncludeFirstByte {
+				table.Rows[i][1] = formatExportTimestamp(firstByteTime(line), opts.TimestampFormat, origin, prevFirst)
+			}
+		}
+	}
+
+	if opts.Bookmarks != nil {
+		col := len(opts.timestampColumns())
+		for i, line := range table.Lines {
+			if note, ok := opts.Bookmarks[line.Seq]; ok {
+				table.Rows[i][col] = bookmarkCell(note)
+			}
+		}
+	}
+
+	if opts.InferTypes {
+		table.Types = inferColumnTypes(table.Rows, table.width())
+	}
+	for i, typ := range opts.CustomTypes {
+		ct, ok := columnTypeOf(typ)
+		if !ok {
+			continue
+		}
+		if table.Types == nil {
+			table.Types = make([]ColumnType, table.width())
+		}
+		col := prefix + i
+		if col >= len(table.Types) {
+			continue
+		}
+		// A value that doesn't fit would be written as null or left blank.
+		if ct != ColumnString && !fitsType(table.Rows, col, ct) {
+			ct = ColumnString
+			result.Untyped = append(result.Untyped, table.columnName(col))
+		}
+		table.Types[col] = ct
+	}
+	return table, result
+}
+
+// firstByteTime returns when a line's first byte arrived, or its timestamp
+// if that wasn't recorded.
+func firstByteTime(line SerialLine) time.Time {
+	if line.FirstByte.IsZero() {
+		return line.Timestamp
+	}
+	return line.FirstByte
+}
+
+// timestampColumns returns the names of the timestamp columns that lead each
+// row, if any.
+func (opts ExportOptions) timestampColumns() []string {
+	switch {
+	case !opts.IncludeTimestamps:
+		return nil
+	case opts.IncludeFirstByte:
+		return []string{"Timestamp", "First Byte"}
+	}
+	return []string{"Timestamp"}
+}
+
+// prefixColumns returns the names of the timestamp and bookmark columns that
+// lead each row, if any.
+func (opts ExportOptions) prefixColumns() []string {
+	columns := opts.timestampColumns()
+	if opts.Bookmarks != nil {
+		columns = append(columns, "Bookmark")
+	}
+	return columns
+}
+
+// padRecord pads a record with empty cells to n values. Values beyond n are
+// kept, so no data is lost; only trailing empty cells past n are trimmed.
+func padRecord(record []string, n int) []string {
+	for len(record) > n && record[len(record)-1] == "" {
+		record = record[:len(record)-1]
+	}
+	if len(record) >= n {
+		return record
+	}
+	return append(record, make([]string, n-len(record))...)
+}
+
+// inferColumnTypes finds columns whose non-empty values are all integers or
+// all numbers. Columns with no values stay strings.
+func inferColumnTypes(rows [][]string, width int) []ColumnType {
+	types := make([]ColumnType, width)
+	for col := range types {
+		seen := false
+		typ := ColumnInt
+		for _, record := range rows {
+			if col >= len(record) || strings.TrimSpace(record[col]) == "" {
+				continue
+			}
+			seen = true
+			v := strings.TrimSpace(record[col])
+			if _, err := strconv.ParseInt(v, 10, 64); err == nil {
+				continue
+			}
+			if f, err := strconv.ParseFloat(v, 64); err == nil && !math.IsNaN(f) && !math.IsInf(f, 0) {
+				typ = ColumnFloat
+				continue
+			}
+			typ = ColumnString
+			break
+		}
+		if seen {
+			types[col] = typ
+		}
+	}
+	return types
+}
+
+// fitsType reports whether every non-empty cell in a column converts to a
+// numeric type.
+func fitsType(rows [][]string, col int, typ ColumnType) bool {
+	for _, record := range rows {
+		if col >= len(record) || strings.TrimSpace(record[col]) == "" {
+			continue
+		}
+		if _, ok := typedValue(record[col], typ); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// typedValue converts a cell of a numeric column to int64 or float64. It
+// reports false for empty cells.
+func typedValue(value string, typ ColumnType) (any, bool) {
+	value = strings.TrimSpace(value)
+	switch typ {
+	case ColumnInt:
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return n, true
+		}
+	case ColumnFloat:
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f, true
+		}
+	}
+	return nil, false
+}
+
+// columnType returns the inferred type of column i, or ColumnString.
+func (t *ExportTable) columnType(i int) ColumnType {
+	if i < len(t.Types) {
+		return t.Types[i]
+	}
+	return ColumnString
+}
+
+// Export filters and decodes lines, then writes them in the chosen format.
+func Export(lines []SerialLine, opts ExportOptions) (ExportResult, error) {
+	table, result := buildExportTable(lines, opts)
+	if err := exporterByFormat(opts.Format).Write(opts.FilePath, table, opts); err != nil {
+		return ExportResult{}, err
+	}
+	return result, nil
+}
+
+// columnName returns the header for column i, or a positional name when the
+// header is missing or blank.
+func (t *ExportTable) columnName(i int) string {
+	if i < len(t.Header) && strings.TrimSpace(t.Header[i]) != "" {
+		return t.Header[i]
+	}
+	return "Column" + strconv.Itoa(i+1)
+}
+
+// width returns the number of columns needed to hold every row and the header.
+func (t *ExportTable) width() int {
+	n := len(t.Header)
+	for _, r := range t.Rows {
+		n = max(n, len(r))
+	}
+	return n
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestExportTemplateHeaderWithPrefixColumns(t *testing.T) {
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	lines := []SerialLine{
+		{Seq: 1, Timestamp: start, Data: "21.5,40"},
+		{Seq: 2, Timestamp: start.Add(time.Second), Data: "22,41"},
+	}
+	tmpl := HeaderTemplate{Name: "env", Columns: []TemplateColumn{
+		{Name: "temp", Unit: "C", Type: ColumnTypeFloat},
+		{Name: "hum", Type: ColumnTypeInt},
+	}}
+	opts := ExportOptions{
+		IncludeTimestamps: true,
+		IncludeFirstByte:  true,
+		TimestampFormat:   TimestampUnix,
+		CustomHeader:      tmpl.Header(),
+		CustomTypes:       tmpl.Types(),
+		Bookmarks:         map[uint64]string{2: "spike"},
+	}
+
+	table, result := buildExportTable(lines, opts)
+
+	wantHeader := []string{"Timestamp", "First Byte", "Bookmark", "temp [C]", "hum"}
+	if !slices.Equal(table.Header, wantHeader) {
+		t.Errorf("header %v, want %v", table.Header, wantHeader)
+	}
+	if result.Rows != 2 || result.Mismatched != 0 {
+		t.Errorf("result %+v, want 2 rows and none mismatched", result)
+	}
+	for i, want := range [][]string{{"", "21.5", "40"}, {"spike", "22", "41"}} {
+		row := table.Rows[i]
+		if len(row) != 5 || row[0] == "" || row[1] == "" || !slices.Equal(row[2:], want) {
+			t.Errorf("row %d: %q, want two timestamps then %q", i, row, want)
+		}
+	}
+	wantTypes := []ColumnType{ColumnString, ColumnString, ColumnString, ColumnFloat, ColumnInt}
+	if !slices.Equal(table.Types, wantTypes) {
+		t.Errorf("types %v, want %v", table.Types, wantTypes)
+	}
+}
+
+func TestExportPadKeepsExtraFields(t *testing.T) {
+	lines := []SerialLine{
+		{Seq: 1, Data: "1,2"},
+		{Seq: 2, Data: "3,4,5,6"},
+		{Seq: 3, Data: "7"},
+	}
+	table, result := buildExportTable(lines, ExportOptions{CustomHeader: []string{"a", "b"}})
+	if result.Mismatched != 2 {
+		t.Errorf("mismatched %d, want 2", result.Mismatched)
+	}
+	want := [][]string{{"1", "2"}, {"3", "4", "5", "6"}, {"7", ""}}
+	for i, row := range table.Rows {
+		if !slices.Equal(row, want[i]) {
+			t.Errorf("row %d: %q, want %q", i, row, want[i])
+		}
+	}
+}
+
+func TestExportDeltaFollowsArrivalOrder(t *testing.T) {
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	// Sorted by value, as the table view may show them.
+	lines := []SerialLine{
+		{Seq: 3, Timestamp: start.Add(3 * time.Second), Data: "1"},
+		{Seq: 1, Timestamp: start, Data: "2"},
+		{Seq: 2, Timestamp: start.Add(time.Second), Data: "3"},
+	}
+	table, _ := buildExportTable(lines, ExportOptions{IncludeTimestamps: true, TimestampFormat: TimestampDelta})
+	var got []string
+	for _, row := range table.Rows {
+		got = append(got, row[0])
+	}
+	if want := []string{"2.000000", "0.000000", "1.000000"}; !slices.Equal(got, want) {
+		t.Errorf("deltas %v, want %v", got, want)
+	}
+}
+
+func TestExportSinceConnectUsesEachConnection(t *testing.T) {
+	first := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	second := first.Add(time.Minute)
+	lines := []SerialLine{
+		{Seq: 1, Timestamp: first.Add(-time.Second), Data: "rendered"},
+		{Seq: 2, Timestamp: first.Add(2 * time.Second), Data: "a"},
+		{Seq: 3, Timestamp: second.Add(500 * time.Millisecond), Data: "b"},
+	}
+	table, _ := buildExportTable(lines, ExportOptions{
+		IncludeTimestamps: true,
+		TimestampFormat:   TimestampSinceConnect,
+		Connects:          []time.Time{first, second},
+	})
+	var got []string
+	for _, row := range table.Rows {
+		got = append(got, row[0])
+	}
+	if want := []string{"0.000000", "2.000000", "0.500000"}; !slices.Equal(got, want) {
+		t.Errorf("since connect %v, want %v", got, want)
+	}
+}
+
+func TestExportSelectionBySeq(t *testing.T) {
+	// Lines read in one burst share a timestamp, so only Seq tells them apart.
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	lines := []SerialLine{
+		{Seq: 1, Timestamp: now, Data: "a"},
+		{Seq: 2, Timestamp: now, Data: "b"},
+		{Seq: 3, Timestamp: now, Data: "c"},
+		{Seq: 4, Timestamp: now, Data: "d"},
+	}
+	table, _ := buildExportTable(lines, ExportOptions{FilterBySeq: true, StartSeq: 2, EndSeq: 3})
+	var got []string
+	for _, row := range table.Rows {
+		got = append(got, row[0])
+	}
+	if want := []string{"b", "c"}; !slices.Equal(got, want) {
+		t.Errorf("exported %q, want %q", got, want)
+	}
+}
+
+func TestExportTemplateTypeFallsBackToText(t *testing.T) {
+	lines := []SerialLine{{Seq: 1, Data: "1,0"}, {Seq: 2, Data: "2,0.5"}, {Seq: 3, Data: "3,ERR"}}
+	path := filepath.Join(t.TempDir(), "out.jsonl")
+	result, err := Export(lines, ExportOptions{
+		FilePath:     path,
+		Format:       FormatJSONL,
+		CustomHeader: []string{"n", "v"},
+		CustomTypes:  []string{ColumnTypeInt, ColumnTypeInt},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"n":1,"v":"0"}` + "\n" + `{"n":2,"v":"0.5"}` + "\n" + `{"n":3,"v":"ERR"}` + "\n"
+	if string(data) != want {
+		t.Errorf("exported\n%s\nwant\n%s", data, want)
+	}
+	if !slices.Equal(result.Untyped, []string{"v"}) {
+		t.Errorf("untyped columns %v, want [v]", result.Untyped)
+	}
+}
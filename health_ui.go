@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// lagWarning is how far the display may fall behind the port before the
+// banner says so.
+const lagWarning = 2 * time.Second
+
+// healthAck is what the user last dismissed, so the banner only returns for
+// new losses.
+type healthAck struct {
+	health LinkHealth
+	drops  uint64 // LineStats drops
+}
+
+// buildHealthBanner returns the data loss warning shown above the output. It
+// stays hidden while the link is healthy.
+func (ui *AppUI) buildHealthBanner() fyne.CanvasObject {
+	ui.healthLabel = widget.NewLabel("")
+	ui.healthLabel.Wrapping = fyne.TextWrapWord
+	dismissBtn := widget.NewButton("Dismiss", func() {
+		ui.healthAckMu.Lock()
+		ui.healthAck = ui.healthSeen
+		ui.healthAckMu.Unlock()
+		ui.healthBanner.Hide()
+	})
+	bg := canvas.NewRectangle(color.Transparent)
+	bg.FillColor = withAlpha(theme.Color(theme.ColorNameWarning), 0x40)
+	icon := widget.NewIcon(theme.WarningIcon())
+	ui.healthBanner = container.NewStack(bg, container.NewBorder(nil, nil, icon, dismissBtn, ui.healthLabel))
+	ui.healthBanner.Hide()
+	return ui.healthBanner
+}
+
+// withAlpha returns c with its alpha replaced.
+func withAlpha(c color.Color, a uint8) color.Color {
+	r, g, b, _ := c.RGBA()
+	return color.NRGBA{R: uint8(r >> 8), G: uint8(g >> 8), B: uint8(b >> 8), A: a}
+}
+
+// resetHealthAck forgets dismissed warnings when a new reader starts with
+// fresh counters.
+func (ui *AppUI) resetHealthAck() {
+	ui.healthAckMu.Lock()
+	ui.healthAck = healthAck{drops: ui.stats.Snapshot().Drops}
+	ui.healthAckMu.Unlock()
+}
+
+// watchHealth checks for data loss once a second for the life of the app.
+func (ui *AppUI) watchHealth() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for range ticker.C {
+		if !ui.connected.Load() {
+			continue
+		}
+		h := ui.serial.Health()
+		st := ui.stats.Snapshot()
+
+		ui.healthAckMu.Lock()
+		ui.healthSeen = healthAck{health: h, drops: st.Drops}
+		warnings := healthWarnings(h, st, ui.healthAck)
+		ui.healthAckMu.Unlock()
+
+		fyne.Do(func() {
+			if len(warnings) == 0 {
+				ui.healthBanner.Hide()
+				return
+			}
+			ui.healthLabel.SetText(strings.Join(warnings, "; "))
+			ui.healthBanner.Show()
+		})
+	}
+}
+
+// healthWarnings describes the losses and delays since ack.
+func healthWarnings(h LinkHealth, st StatsSnapshot, ack healthAck) []string {
+	var w []string
+	if n := since(h.OS.Overrun+h.OS.BufferOverrun, ack.health.OS.Overrun+ack.health.OS.BufferOverrun); n > 0 {
+		w = append(w, fmt.Sprintf("%d characters lost to serial buffer overruns", n))
+	}
+	if n := since(h.OS.Frame+h.OS.Parity, ack.health.OS.Frame+ack.health.OS.Parity); n > 0 {
+		w = append(w, fmt.Sprintf("%d framing or parity errors; check the baud rate and framing", n))
+	}
+	if n := since(h.Dropped, ack.health.Dropped); n > 0 {
+		w = append(w, fmt.Sprintf("%d lines dropped because the display fell behind", n))
+	}
+	if h.Policy == BackpressureBlock && h.Stalled-ack.health.Stalled >= time.Second {
+		w = append(w, fmt.Sprintf("reading paused %s waiting for the display; the port's buffer may overflow",
+			(h.Stalled-ack.health.Stalled).Round(time.Millisecond)))
+	}
+	if h.Spooled > 0 {
+		w = append(w, fmt.Sprintf("%d lines spilled to disk waiting for the display", h.Spooled))
+	}
+	if n := since(st.Drops, ack.drops); n > 0 {
+		w = append(w, fmt.Sprintf("%d lines dropped by slow API, MQTT or sink consumers", n))
+	}
+	if st.Lag >= lagWarning {
+		w = append(w, fmt.Sprintf("display is %s behind the port", st.Lag.Round(100*time.Millisecond)))
+	}
+	return w
+}
+
+// since returns how much a counter grew from base, or 0 if it was reset.
+func since(count, base uint64) uint64 {
+	if count < base {
+		return 0
+	}
+	return count - base
+}
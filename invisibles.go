@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// How control characters and trailing spaces are displayed.
+const (
+	InvisiblesHidden   = "Hidden"
+	InvisiblesEscapes  = `Escapes (\r)`
+	InvisiblesPictures = "Symbols (␍)"
+)
+
+var invisiblesModes = []string{InvisiblesHidden, InvisiblesEscapes, InvisiblesPictures}
+
+// showInvisibles makes control characters and trailing spaces in text
+// visible, as C-style escapes or Unicode control pictures. Other text is
+// left alone.
+func showInvisibles(text, mode string) string {
+	if mode != InvisiblesEscapes && mode != InvisiblesPictures {
+		return text
+	}
+	// Spaces are trailing if only spaces and control characters follow them,
+	// such as a kept \r.
+	end := len(strings.TrimRightFunc(text, func(r rune) bool { return r == ' ' || isControl(r) }))
+	space := `\x20`
+	if mode == InvisiblesPictures {
+		space = "␠"
+	}
+
+	var b strings.Builder
+	for i, r := range text {
+		switch {
+		case r == ' ' && i >= end:
+			b.WriteString(space)
+		case mode == InvisiblesPictures:
+			b.WriteString(controlPicture(r))
+		default:
+			b.WriteString(controlEscape(r))
+		}
+	}
+	return b.String()
+}
+
+// isControl reports whether r is a C0 or C1 control character or DEL.
+func isControl(r rune) bool {
+	return r < 0x20 || r >= 0x7F && r < 0xA0
+}
+
+// controlEscape returns r, or its escape if it is a control character.
+func controlEscape(r rune) string {
+	switch r {
+	case 0:
+		return `\0`
+	case '\t':
+		return `\t`
+	case '\n':
+		return `\n`
+	case '\r':
+		return `\r`
+	case 0x1B:
+		return `\e`
+	}
+	switch {
+	case r < 0x20 || r == 0x7F:
+		return fmt.Sprintf(`\x%02X`, r)
+	case isControl(r):
+		return fmt.Sprintf(`\u%04X`, r)
+	}
+	return string(r)
+}
+
+// controlPicture returns r, or its symbol from the Control Pictures block if
+// it is a control character. C1 controls, which have no symbol, are escaped.
+func controlPicture(r rune) string {
+	switch {
+	case r < 0x20:
+		return string(0x2400 + r)
+	case r == 0x7F:
+		return "␡"
+	case isControl(r):
+		return fmt.Sprintf(`\u%04X`, r)
+	}
+	return string(r)
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// JSONLOptions configures the JSON Lines exporter.
+type JSONLOptions struct {
+	IncludeRaw bool // add the line as received as a "raw" key, bytes that aren't valid UTF-8 escaped
+}
+
+// jsonlExporter writes one JSON object per line, with keys in column order.
+type jsonlExporter struct{}
+
+func (jsonlExporter) Format() string       { return FormatJSONL }
+func (jsonlExporter) Extensions() []string { return []string{".jsonl", ".ndjson"} }
+
+func (jsonlExporter) Write(path string, table *ExportTable, opts ExportOptions) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	for i, record := range table.Rows {
+		// Built by hand rather than from a map so keys keep the column order.
+		w.WriteByte('{')
+		for j, value := range record {
+			if j > 0 {
+				w.WriteByte(',')
+			}
+			writeJSONPair(w, table.columnName(j), value, table.columnType(j))
+		}
+		if opts.JSONL.IncludeRaw {
+			if len(record) > 0 {
+				w.WriteByte(',')
+			}
+			writeJSONPair(w, "raw", decodeText(table.Lines[i].Data, EncodingUTF8), ColumnString)
+		}
+		w.WriteString("}\n")
+	}
+
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	return nil
+}
+
+// writeJSONPair writes "key":value, as a number for numeric columns and null
+// for their empty cells.
+func writeJSONPair(w *bufio.Writer, key, value string, typ ColumnType) {
+	k, _ := json.Marshal(key)
+	w.Write(k)
+	w.WriteByte(':')
+	if typ != ColumnString {
+		if n, ok := typedValue(value, typ); ok {
+			v, _ := json.Marshal(n)
+			w.Write(v)
+		} else {
+			w.WriteString("null")
+		}
+		return
+	}
+	v, _ := json.Marshal(value)
+	w.Write(v)
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+const histogramBins = 20
+
+// gapStats summarises the gaps between consecutive lines.
+type gapStats struct {
+	count                  int
+	min, max, mean, stddev time.Duration
+	p50, p95, p99          time.Duration
+	binWidth               time.Duration
+	bins                   []int // counts from min upward, binWidth each
+	overflow               int   // gaps above the 99th percentile, left out of the bins
+}
+
+// lineGaps returns the time between each line and the one before it.
+func lineGaps(lines []SerialLine) []time.Duration {
+	if len(lines) < 2 {
+		return nil
+	}
+	gaps := make([]time.Duration, len(lines)-1)
+	for i := 1; i < len(lines); i++ {
+		gaps[i-1] = lines[i].Timestamp.Sub(lines[i-1].Timestamp)
+	}
+	return gaps
+}
+
+// computeGapStats bins gaps between the minimum and the 99th percentile so a
+// few long pauses, such as a device reset, don't flatten the histogram.
+func computeGapStats(gaps []time.Duration) gapStats {
+	st := gapStats{count: len(gaps)}
+	if len(gaps) == 0 {
+		return st
+	}
+	sorted := slices.Clone(gaps)
+	slices.Sort(sorted)
+	percentile := func(p float64) time.Duration {
+		return sorted[int(math.Round(p*float64(len(sorted)-1)))]
+	}
+	st.min, st.max = sorted[0], sorted[len(sorted)-1]
+	st.p50, st.p95, st.p99 = percentile(0.50), percentile(0.95), percentile(0.99)
+
+	var sum float64
+	for _, g := range gaps {
+		sum += float64(g)
+	}
+	mean := sum / float64(len(gaps))
+	var variance float64
+	for _, g := range gaps {
+		variance += (float64(g) - mean) * (float64(g) - mean)
+	}
+	st.mean = time.Duration(mean)
+	st.stddev = time.Duration(math.Sqrt(variance / float64(len(gaps))))
+
+	st.binWidth = max((st.p99-st.min+histogramBins-1)/histogramBins, time.Microsecond)
+	st.bins = make([]int, histogramBins)
+	for _, g := range gaps {
+		if g > st.p99 {
+			st.overflow++
+			continue
+		}
+		st.bins[min(int((g-st.min)/st.binWidth), histogramBins-1)]++
+	}
+	return st
+}
+
+// formatGap renders a gap in milliseconds with microsecond resolution.
+func formatGap(d time.Duration) string {
+	return strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', 3, 64) + " ms"
+}
+
+// showLatencyDialog shows a histogram of the gaps between received lines.
+func (ui *AppUI) showLatencyDialog() {
+	summary := widget.NewLabel("")
+	summary.TextStyle = fyne.TextStyle{Monospace: true}
+	bars := container.NewVBox()
+
+	update := func() {
+		ui.mu.Lock()
+		st := computeGapStats(lineGaps(ui.lines))
+		ui.mu.Unlock()
+
+		bars.RemoveAll()
+		if st.count == 0 {
+			summary.SetText("Need at least two lines.")
+			return
+		}
+		summary.SetText(strings.Join([]string{
+			fmt.Sprintf("Gaps: %d   min %s   max %s", st.count, formatGap(st.min), formatGap(st.max)),
+			fmt.Sprintf("Mean %s   std dev (jitter) %s", formatGap(st.mean), formatGap(st.stddev)),
+			fmt.Sprintf("p50 %s   p95 %s   p99 %s", formatGap(st.p50), formatGap(st.p95), formatGap(st.p99)),
+		}, "\n"))
+
+		peak := slices.Max(st.bins)
+		for i, n := range st.bins {
+			lo := st.min + time.Duration(i)*st.binWidth
+			label := widget.NewLabel(fmt.Sprintf("%s – %s", formatGap(lo), formatGap(lo+st.binWidth)))
+			label.TextStyle = fyne.TextStyle{Monospace: true}
+			bar := widget.NewProgressBar()
+			bar.Max = float64(max(peak, 1))
+			bar.SetValue(float64(n))
+			count := n
+			bar.TextFormatter = func() string { return strconv.Itoa(count) }
+			bars.Add(container.NewBorder(nil, nil, label, nil, bar))
+		}
+		if st.overflow > 0 {
+			bars.Add(widget.NewLabel(fmt.Sprintf("%d gaps above p99 not shown", st.overflow)))
+		}
+	}
+	update()
+
+	refreshBtn := widget.NewButton("Refresh", update)
+	scroll := container.NewVScroll(bars)
+	scroll.SetMinSize(fyne.NewSize(560, 420))
+	content := container.NewBorder(container.NewVBox(summary, refreshBtn), nil, nil, nil, scroll)
+	dialog.ShowCustom("Line Latency", "Close", content, ui.window)
+}
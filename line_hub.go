@@ -0,0 +1,48 @@
+package main
+
+import "sync"
+
+// lineHub fans received lines out to subscribers such as the HTTP API.
+// Lines are dropped for a subscriber that falls behind, so a slow client
+// never stalls the monitor.
+type lineHub struct {
+	mu   sync.Mutex
+	subs map[chan SerialLine]struct{}
+}
+
+// Subscribe returns a channel of lines received from now on. Call the
+// returned function to unsubscribe; the channel is then closed.
+func (h *lineHub) Subscribe() (<-chan SerialLine, func()) {
+	ch := make(chan SerialLine, 256)
+	h.mu.Lock()
+	if h.subs == nil {
+		h.subs = make(map[chan SerialLine]struct{})
+	}
+	h.subs[ch] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.subs, ch)
+			h.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// Publish hands a line to every subscriber and returns how many dropped it.
+func (h *lineHub) Publish(line SerialLine) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	dropped := 0
+	for ch := range h.subs {
+		select {
+		case ch <- line:
+		default:
+			dropped++
+		}
+	}
+	return dropped
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Modbus data tables.
+const (
+	ModbusCoils            = "Coils"
+	ModbusDiscreteInputs   = "Discrete Inputs"
+	ModbusHoldingRegisters = "Holding Registers"
+	ModbusInputRegisters   = "Input Registers"
+)
+
+var modbusTables = []string{ModbusCoils, ModbusDiscreteInputs, ModbusHoldingRegisters, ModbusInputRegisters}
+
+// modbusWritableTables lists the tables a master can write to.
+var modbusWritableTables = []string{ModbusCoils, ModbusHoldingRegisters}
+
+// Modbus function codes.
+const (
+	modbusReadCoils            = 0x01
+	modbusReadDiscreteInputs   = 0x02
+	modbusReadHoldingRegisters = 0x03
+	modbusReadInputRegisters   = 0x04
+	modbusWriteSingleCoil      = 0x05
+	modbusWriteSingleRegister  = 0x06
+)
+
+// modbusExceptions maps exception codes to their standard names.
+var modbusExceptions = map[byte]string{
+	0x01: "illegal function",
+	0x02: "illegal data address",
+	0x03: "illegal data value",
+	0x04: "slave device failure",
+	0x05: "acknowledge",
+	0x06: "slave device busy",
+	0x0B: "gateway target failed to respond",
+}
+
+// ModbusPoll is one row of the polling table.
+type ModbusPoll struct {
+	Name    string
+	Slave   byte
+	Table   string // one of modbusTables
+	Address uint16
+	Count   uint16
+}
+
+// ModbusClient is a Modbus RTU master on top of an open serial port.
+type ModbusClient struct {
+	rw       io.ReadWriter
+	frameGap time.Duration // silent interval (t3.5) required between frames
+	charTime time.Duration
+	Timeout  time.Duration // how long to wait for a slave to start responding
+	lastIO   time.Time
+}
+
+// NewModbusClient creates a client whose frame timing is derived from the baud
+// rate and the bits per character on the wire. RTU framing is normally 11 bits:
+// start, 8 data, parity (or a second stop bit), stop.
+func NewModbusClient(rw io.ReadWriter, baudRate, frameBits int) *ModbusClient {
+	charTime := time.Duration(frameBits) * time.Second / time.Duration(baudRate)
+	frameGap := charTime * 7 / 2
+	// The spec fixes t3.5 at 1.75 ms above 19200 baud.
+	if baudRate > 19200 {
+		frameGap = 1750 * time.Microsecond
+	}
+	return &ModbusClient{
+		rw:       rw,
+		frameGap: frameGap,
+		charTime: charTime,
+		Timeout:  500 * time.Millisecond,
+	}
+}
+
+// crc16Modbus computes the CRC-16/MODBUS checksum (poly 0xA001 reflected, init 0xFFFF).
+func crc16Modbus(data []byte) uint16 {
+	crc := uint16(0xFFFF)
+	for _, b := range data {
+		crc ^= uint16(b)
+		for i := 0; i < 8; i++ {
+			if crc&1 != 0 {
+				crc = crc>>1 ^ 0xA001
+			} else {
+				crc >>= 1
+			}
+		}
+	}
+	return crc
+}
+
+// flushInput discards bytes waiting in the port's input buffer, if the port
+// supports it.
+func (c *ModbusClient) flushInput() {
+	if f, ok := c.rw.(interface{ ResetInputBuffer() error }); ok {
+		f.ResetInputBuffer()
+	}
+}
+
+// transact sends a request PDU to a slave and returns the response PDU.
+// respLen is the expected length of a normal response PDU.
+func (c *ModbusClient) transact(slave byte, pdu []byte, respLen int) ([]byte, error) {
+	// Honour the inter-frame silent interval since the last traffic.
+	if wait := c.frameGap - time.Since(c.lastIO); wait > 0 {
+		time.Sleep(wait)
+	}
+
+	// A late reply to an earlier request would be read as the head of this one.
+	c.flushInput()
+
+	adu := append([]byte{slave}, pdu...)
+	adu = binary.LittleEndian.AppendUint16(adu, crc16Modbus(adu))
+	if _, err := c.rw.Write(adu); err != nil {
+		return nil, fmt.Errorf("failed to write request: %w", err)
+	}
+	defer func() { c.lastIO = time.Now() }()
+
+	// Allow for the time the request takes to go out on the wire.
+	timeout := c.Timeout + c.charTime*time.Duration(len(adu))
+
+	head := make([]byte, 2)
+	if err := readFull(c.rw, head, timeout, nil); err != nil {
+		return nil, fmt.Errorf("no response from slave %d: %w", slave, err)
+	}
+
+	rest := respLen - 1 + 2 // PDU minus function code, plus CRC
+	if head[1]&0x80 != 0 {
+		rest = 1 + 2 // exception code plus CRC
+	}
+	body := make([]byte, rest)
+	if err := readFull(c.rw, body, c.charTime*time.Duration(rest)+c.Timeout, nil); err != nil {
+		return nil, fmt.Errorf("incomplete response from slave %d: %w", slave, err)
+	}
+
+	frame := append(head, body...)
+	n := len(frame)
+	if crc16Modbus(frame[:n-2]) != binary.LittleEndian.Uint16(frame[n-2:]) {
+		return nil, fmt.Errorf("CRC error in response from slave %d", slave)
+	}
+	if frame[0] != slave {
+		return nil, fmt.Errorf("response from unexpected slave %d", frame[0])
+	}
+	if frame[1]&0x80 != 0 {
+		code := frame[2]
+		name, ok := modbusExceptions[code]
+		if !ok {
+			name = "unknown exception"
+		}
+		return nil, fmt.Errorf("slave %d exception %d: %s", slave, code, name)
+	}
+	if frame[1] != pdu[0] {
+		return nil, fmt.Errorf("response has unexpected function code %d", frame[1])
+	}
+	return frame[1 : n-2], nil
+}
+
+// Read reads count coils, discrete inputs or registers from a slave table.
+// Bits are returned as 0 or 1.
+func (c *ModbusClient) Read(slave byte, table string, address, count uint16) ([]int, error) {
+	var fc byte
+	var respLen int
+	switch table {
+	case ModbusCoils, ModbusDiscreteInputs:
+		if count == 0 || count > 2000 {
+			return nil, fmt.Errorf("bit count must be 1-2000")
+		}
+		fc = modbusReadCoils
+		if table == ModbusDiscreteInputs {
+			fc = modbusReadDiscreteInputs
+		}
+		respLen = 2 + int(count+7)/8
+	case ModbusHoldingRegisters, ModbusInputRegisters:
+		if count == 0 || count > 125 {
+			return nil, fmt.Errorf("register count must be 1-125")
+		}
+		fc = modbusReadHoldingRegisters
+		if table == ModbusInputRegisters {
+			fc = modbusReadInputRegisters
+		}
+		respLen = 2 + int(count)*2
+	default:
+		return nil, fmt.Errorf("unknown Modbus table: %s", table)
+	}
+
+	pdu := []byte{fc}
+	pdu = binary.BigEndian.AppendUint16(pdu, address)
+	pdu = binary.BigEndian.AppendUint16(pdu, count)
+	resp, err := c.transact(slave, pdu, respLen)
+	if err != nil {
+		return nil, err
+	}
+	if int(resp[1]) != respLen-2 {
+		return nil, fmt.Errorf("unexpected byte count %d", resp[1])
+	}
+
+	data := resp[2:]
+	values := make([]int, count)
+	for i := range values {
+		if fc == modbusReadCoils || fc == modbusReadDiscreteInputs {
+			values[i] = int(data[i/8]>>(i%8)) & 1
+		} else {
+			values[i] = int(binary.BigEndian.Uint16(data[i*2:]))
+		}
+	}
+	return values, nil
+}
+
+// Write writes a single coil (0 or non-zero) or holding register on a slave.
+func (c *ModbusClient) Write(slave byte, table string, address uint16, value int) error {
+	var fc byte
+	var raw uint16
+	switch table {
+	case ModbusCoils:
+		fc = modbusWriteSingleCoil
+		if value != 0 {
+			raw = 0xFF00
+		}
+	case ModbusHoldingRegisters:
+		if value < 0 || value > 0xFFFF {
+			return fmt.Errorf("register value must be 0-65535")
+		}
+		fc = modbusWriteSingleRegister
+		raw = uint16(value)
+	default:
+		return fmt.Errorf("%s are read-only", table)
+	}
+
+	pdu := []byte{fc}
+	pdu = binary.BigEndian.AppendUint16(pdu, address)
+	pdu = binary.BigEndian.AppendUint16(pdu, raw)
+	resp, err := c.transact(slave, pdu, len(pdu))
+	if err != nil {
+		return err
+	}
+	// Single writes echo the request.
+	if string(resp) != string(pdu) {
+		return errors.New("write not echoed by slave")
+	}
+	return nil
+}
+
+// PollModbus reads every row of the polling table and returns the values as
+// strings in table order, one per coil or register. Rows that fail leave
+// empty fields so columns stay aligned; the first error is returned.
+func PollModbus(c *ModbusClient, polls []ModbusPoll) ([]string, error) {
+	var fields []string
+	var firstErr error
+	for _, p := range polls {
+		values, err := c.Read(p.Slave, p.Table, p.Address, p.Count)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("%s: %w", p.Name, err)
+			}
+			fields = append(fields, make([]string, p.Count)...)
+			continue
+		}
+		for _, v := range values {
+			fields = append(fields, strconv.Itoa(v))
+		}
+	}
+	return fields, firstErr
+}
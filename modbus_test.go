@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/binary"
+	"slices"
+	"testing"
+	"time"
+)
+
+// serveHoldingRegisters answers one read holding registers request on port
+// with registers holding their own address.
+func serveHoldingRegisters(t *testing.T, port *loopbackEnd) {
+	req := make([]byte, 8)
+	if err := readFull(port, req, time.Second, nil); err != nil {
+		t.Errorf("slave: %v", err)
+		return
+	}
+	address := binary.BigEndian.Uint16(req[2:])
+	count := binary.BigEndian.Uint16(req[4:])
+	resp := []byte{req[0], req[1], byte(count * 2)}
+	for i := range count {
+		resp = binary.BigEndian.AppendUint16(resp, address+i)
+	}
+	resp = binary.LittleEndian.AppendUint16(resp, crc16Modbus(resp))
+	port.Write(resp)
+}
+
+func TestModbusIgnoresLateReply(t *testing.T) {
+	master, slave := newLoopback()
+	c := NewModbusClient(master, 9600, 11)
+	c.Timeout = 100 * time.Millisecond
+
+	// The tail of a reply to an earlier, timed-out request.
+	slave.Write([]byte{0x01, 0x03, 0x02, 0x00, 0x2A})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		serveHoldingRegisters(t, slave)
+	}()
+	values, err := c.Read(1, ModbusHoldingRegisters, 100, 3)
+	<-done
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{100, 101, 102}; !slices.Equal(values, want) {
+		t.Errorf("got %v, want %v", values, want)
+	}
+}
+
+func TestModbusCharTimeFollowsFraming(t *testing.T) {
+	// 8N1 is 10 bits per character, 8E1 is 11.
+	if got, want := NewModbusClient(nil, 9600, 10).charTime, 10*time.Second/9600; got != want {
+		t.Errorf("8N1 char time %s, want %s", got, want)
+	}
+	if got, want := NewModbusClient(nil, 9600, 11).charTime, 11*time.Second/9600; got != want {
+		t.Errorf("8E1 char time %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,365 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+var modbusColumns = []string{"Name", "Slave", "Table", "Address", "Count", "Values"}
+
+// modbusView is the Modbus RTU master window. Each polling cycle is appended
+// to the output as one comma-separated line, so the values flow into the same
+// display and CSV export as text lines.
+type modbusView struct {
+	ui      *AppUI
+	window  fyne.Window
+	table   *widget.Table
+	status  *widget.Label
+	pollBtn *widget.Button
+
+	mu       sync.Mutex
+	values   [][]string // latest values per poll row
+	selected int
+	stopCh   chan struct{}
+	client   *ModbusClient // shared by polls and writes so t3.5 holds between them
+}
+
+func (ui *AppUI) showModbusWindow() {
+	if ui.modbus != nil {
+		ui.modbus.window.RequestFocus()
+		return
+	}
+
+	mv := &modbusView{
+		ui:       ui,
+		window:   fyne.CurrentApp().NewWindow("Modbus RTU"),
+		selected: -1,
+	}
+	ui.modbus = mv
+	mv.build()
+	mv.window.SetOnClosed(func() {
+		mv.stopPolling()
+		ui.modbus = nil
+	})
+	mv.window.Resize(fyne.NewSize(700, 450))
+	mv.window.Show()
+}
+
+func (mv *modbusView) build() {
+	mv.table = widget.NewTableWithHeaders(
+		func() (int, int) {
+			return len(mv.ui.modbusPolls), len(modbusColumns)
+		},
+		func() fyne.CanvasObject {
+			return widget.NewLabel("")
+		},
+		func(id widget.TableCellID, obj fyne.CanvasObject) {
+			obj.(*widget.Label).SetText(mv.cellText(id))
+		},
+	)
+	mv.table.ShowHeaderColumn = false
+	mv.table.UpdateHeader = func(id widget.TableCellID, obj fyne.CanvasObject) {
+		if id.Row < 0 && id.Col >= 0 {
+			obj.(*widget.Label).SetText(modbusColumns[id.Col])
+		}
+	}
+	for i, w := range []float32{120, 60, 140, 80, 60, 220} {
+		mv.table.SetColumnWidth(i, w)
+	}
+	mv.table.OnSelected = func(id widget.TableCellID) {
+		mv.selected = id.Row
+	}
+
+	// New poll row
+	nameEntry := widget.NewEntry()
+	nameEntry.SetPlaceHolder("Name")
+	slaveEntry := widget.NewEntry()
+	slaveEntry.SetText("1")
+	tableSelect := widget.NewSelect(modbusTables, nil)
+	tableSelect.SetSelected(ModbusHoldingRegisters)
+	addrEntry := widget.NewEntry()
+	addrEntry.SetText("0")
+	countEntry := widget.NewEntry()
+	countEntry.SetText("1")
+
+	addBtn := widget.NewButton("Add", func() {
+		slave, err := parseModbusSlave(slaveEntry.Text)
+		if err != nil {
+			dialog.ShowError(err, mv.window)
+			return
+		}
+		addr, err := strconv.ParseUint(strings.TrimSpace(addrEntry.Text), 0, 16)
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("invalid address: %s", addrEntry.Text), mv.window)
+			return
+		}
+		count, err := strconv.ParseUint(strings.TrimSpace(countEntry.Text), 10, 16)
+		if err != nil || count == 0 {
+			dialog.ShowError(fmt.Errorf("invalid count: %s", countEntry.Text), mv.window)
+			return
+		}
+		name := strings.TrimSpace(nameEntry.Text)
+		if name == "" {
+			name = fmt.Sprintf("%d:%d", slave, addr)
+		}
+
+		mv.mu.Lock()
+		mv.ui.modbusPolls = append(mv.ui.modbusPolls, ModbusPoll{
+			Name:    name,
+			Slave:   slave,
+			Table:   tableSelect.Selected,
+			Address: uint16(addr),
+			Count:   uint16(count),
+		})
+		mv.values = append(mv.values, nil)
+		mv.mu.Unlock()
+		mv.table.Refresh()
+	})
+
+	removeBtn := widget.NewButton("Remove Selected", func() {
+		mv.mu.Lock()
+		if mv.selected >= 0 && mv.selected < len(mv.ui.modbusPolls) {
+			mv.ui.modbusPolls = append(mv.ui.modbusPolls[:mv.selected], mv.ui.modbusPolls[mv.selected+1:]...)
+			if mv.selected < len(mv.values) {
+				mv.values = append(mv.values[:mv.selected], mv.values[mv.selected+1:]...)
+			}
+		}
+		mv.selected = -1
+		mv.mu.Unlock()
+		mv.table.UnselectAll()
+		mv.table.Refresh()
+	})
+
+	addRow := container.NewGridWithColumns(7,
+		nameEntry, slaveEntry, tableSelect, addrEntry, countEntry, addBtn, removeBtn)
+
+	// Polling controls
+	intervalEntry := widget.NewEntry()
+	intervalEntry.SetText("1000")
+	mv.status = widget.NewLabel("Idle")
+	mv.pollBtn = widget.NewButton("Start Polling", nil)
+	mv.pollBtn.OnTapped = func() {
+		if mv.isPolling() {
+			mv.stopPolling()
+			return
+		}
+		ms, err := strconv.Atoi(strings.TrimSpace(intervalEntry.Text))
+		if err != nil || ms <= 0 {
+			dialog.ShowError(fmt.Errorf("invalid interval: %s", intervalEntry.Text), mv.window)
+			return
+		}
+		if err := mv.startPolling(time.Duration(ms) * time.Millisecond); err != nil {
+			dialog.ShowError(err, mv.window)
+		}
+	}
+	pollRow := container.NewHBox(
+		widget.NewLabel("Interval (ms):"), intervalEntry, mv.pollBtn, mv.status)
+
+	// One-shot write
+	wSlaveEntry := widget.NewEntry()
+	wSlaveEntry.SetText("1")
+	wTableSelect := widget.NewSelect(modbusWritableTables, nil)
+	wTableSelect.SetSelected(ModbusHoldingRegisters)
+	wAddrEntry := widget.NewEntry()
+	wAddrEntry.SetPlaceHolder("Address")
+	wValueEntry := widget.NewEntry()
+	wValueEntry.SetPlaceHolder("Value")
+	writeBtn := widget.NewButton("Write", func() {
+		slave, err := parseModbusSlave(wSlaveEntry.Text)
+		if err != nil {
+			dialog.ShowError(err, mv.window)
+			return
+		}
+		addr, err := strconv.ParseUint(strings.TrimSpace(wAddrEntry.Text), 0, 16)
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("invalid address: %s", wAddrEntry.Text), mv.window)
+			return
+		}
+		value, err := strconv.ParseInt(strings.TrimSpace(wValueEntry.Text), 0, 32)
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("invalid value: %s", wValueEntry.Text), mv.window)
+			return
+		}
+		table := wTableSelect.Selected
+		go func() {
+			err := mv.exclusive(func(c *ModbusClient) error {
+				return c.Write(slave, table, uint16(addr), int(value))
+			})
+			fyne.Do(func() {
+				if err != nil {
+					dialog.ShowError(fmt.Errorf("write failed: %w", err), mv.window)
+					return
+				}
+				mv.status.SetText(fmt.Sprintf("Wrote %d to slave %d %s %d", value, slave, table, addr))
+			})
+		}()
+	})
+	writeRow := container.NewGridWithColumns(5,
+		wSlaveEntry, wTableSelect, wAddrEntry, wValueEntry, writeBtn)
+
+	top := container.NewVBox(
+		widget.NewLabelWithStyle("Polling Table", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		addRow,
+		pollRow,
+	)
+	bottom := container.NewVBox(
+		widget.NewLabelWithStyle("Write", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		writeRow,
+	)
+	mv.window.SetContent(container.NewBorder(top, bottom, nil, nil, mv.table))
+}
+
+func (mv *modbusView) cellText(id widget.TableCellID) string {
+	mv.mu.Lock()
+	defer mv.mu.Unlock()
+	if id.Row >= len(mv.ui.modbusPolls) {
+		return ""
+	}
+	p := mv.ui.modbusPolls[id.Row]
+	switch id.Col {
+	case 0:
+		return p.Name
+	case 1:
+		return strconv.Itoa(int(p.Slave))
+	case 2:
+		return p.Table
+	case 3:
+		return strconv.Itoa(int(p.Address))
+	case 4:
+		return strconv.Itoa(int(p.Count))
+	case 5:
+		if id.Row < len(mv.values) {
+			return strings.Join(mv.values[id.Row], ", ")
+		}
+	}
+	return ""
+}
+
+func parseModbusSlave(text string) (byte, error) {
+	slave, err := strconv.ParseUint(strings.TrimSpace(text), 10, 8)
+	if err != nil || slave == 0 || slave > 247 {
+		return 0, fmt.Errorf("invalid slave address (1-247): %s", text)
+	}
+	return byte(slave), nil
+}
+
+// exclusive runs fn with the port to itself and the session's client, which is
+// replaced when the port is reconnected. Input left over from the exchange is
+// flushed so it doesn't reach the line reader.
+func (mv *modbusView) exclusive(fn func(c *ModbusClient) error) error {
+	return mv.ui.serial.Exclusive(func(port io.ReadWriter) error {
+		mv.mu.Lock()
+		if mv.client == nil || mv.client.rw != port {
+			mv.client = NewModbusClient(port, mv.ui.serial.BaudRate(), mv.ui.serial.FrameBits())
+		}
+		c := mv.client
+		mv.mu.Unlock()
+
+		defer c.flushInput()
+		return fn(c)
+	})
+}
+
+func (mv *modbusView) isPolling() bool {
+	mv.mu.Lock()
+	defer mv.mu.Unlock()
+	return mv.stopCh != nil
+}
+
+// startPolling reads the whole table every interval while the port is open.
+func (mv *modbusView) startPolling(interval time.Duration) error {
+	if !mv.ui.connected.Load() {
+		return fmt.Errorf("connect to a port first")
+	}
+
+	mv.mu.Lock()
+	if len(mv.ui.modbusPolls) == 0 {
+		mv.mu.Unlock()
+		return fmt.Errorf("add at least one row to the polling table")
+	}
+	stopCh := make(chan struct{})
+	mv.stopCh = stopCh
+	mv.mu.Unlock()
+
+	mv.pollBtn.SetText("Stop Polling")
+	go mv.pollLoop(interval, stopCh)
+	return nil
+}
+
+func (mv *modbusView) stopPolling() {
+	mv.mu.Lock()
+	if mv.stopCh != nil {
+		close(mv.stopCh)
+		mv.stopCh = nil
+	}
+	mv.mu.Unlock()
+	mv.pollBtn.SetText("Start Polling")
+}
+
+func (mv *modbusView) pollLoop(interval time.Duration, stopCh chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if !mv.ui.connected.Load() {
+			fyne.Do(func() {
+				mv.stopPolling()
+				mv.status.SetText("Stopped: port disconnected")
+			})
+			return
+		}
+
+		mv.mu.Lock()
+		polls := append([]ModbusPoll(nil), mv.ui.modbusPolls...)
+		mv.mu.Unlock()
+
+		var fields []string
+		err := mv.exclusive(func(c *ModbusClient) error {
+			var pollErr error
+			fields, pollErr = PollModbus(c, polls)
+			return pollErr
+		})
+
+		if fields != nil {
+			mv.ui.appendLine(SerialLine{Timestamp: time.Now(), Data: strings.Join(fields, ",")})
+			mv.storeValues(polls, fields)
+		}
+
+		status := fmt.Sprintf("Last poll %s", time.Now().Format("15:04:05"))
+		if err != nil {
+			status = fmt.Sprintf("Error: %v", err)
+		}
+		fyne.Do(func() {
+			mv.status.SetText(status)
+			mv.table.Refresh()
+		})
+
+		select {
+		case <-stopCh:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// storeValues splits a poll cycle's fields back into per-row values for the table.
+func (mv *modbusView) storeValues(polls []ModbusPoll, fields []string) {
+	mv.mu.Lock()
+	defer mv.mu.Unlock()
+	mv.values = make([][]string, len(polls))
+	off := 0
+	for i, p := range polls {
+		end := min(off+int(p.Count), len(fields))
+		mv.values[i] = fields[off:end]
+		off = end
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+const defaultMQTTTopic = "serial/{port}/{field}"
+
+// MQTTSettings configure publishing received lines to an MQTT broker.
+type MQTTSettings struct {
+	Enabled       bool   `json:"enabled"`
+	Broker        string `json:"broker"` // e.g. tcp://localhost:1883
+	ClientID      string `json:"clientId"`
+	Username      string `json:"username,omitempty"`
+	Password      string `json:"password,omitempty"`
+	TopicTemplate string `json:"topicTemplate"` // {port} and {field} are replaced
+	PerField      bool   `json:"perField"`      // publish each decoded field instead of the whole line
+	QoS           int    `json:"qos"`
+	Retain        bool   `json:"retain"`
+	CommandTopic  string `json:"commandTopic,omitempty"` // messages here are written to the port
+}
+
+// validate checks settings before connecting.
+func (s MQTTSettings) validate() error {
+	if strings.TrimSpace(s.Broker) == "" {
+		return fmt.Errorf("no MQTT broker set")
+	}
+	if s.QoS < 0 || s.QoS > 2 {
+		return fmt.Errorf("invalid QoS %d", s.QoS)
+	}
+	if strings.TrimSpace(s.TopicTemplate) == "" {
+		return fmt.Errorf("no topic template set")
+	}
+	if strings.ContainsAny(s.TopicTemplate, "+#") {
+		return fmt.Errorf("topic template must not contain wildcards")
+	}
+	return nil
+}
+
+// mqttTopic expands a topic template. Wildcards and separators in the
+// substituted names are replaced so they stay within one topic level.
+func mqttTopic(template, port, field string) string {
+	clean := strings.NewReplacer("/", "_", "+", "_", "#", "_", " ", "_")
+	if port == "" {
+		port = "unknown"
+	}
+	return strings.NewReplacer(
+		"{port}", clean.Replace(filepath.Base(port)),
+		"{field}", clean.Replace(field),
+	).Replace(template)
+}
+
+// MQTTPublisher publishes received lines to a broker and writes messages on
+// the command topic to the port.
+type MQTTPublisher struct {
+	client      mqtt.Client
+	cfg         MQTTSettings
+	unsubscribe func()
+	done        chan struct{}
+	published   atomic.Uint64
+}
+
+// StartMQTT connects to the broker and publishes lines from ctrl until
+// Close. decoder returns the decoder used to split lines into fields.
+func StartMQTT(cfg MQTTSettings, ctrl MonitorControl, decoder func() Decoder) (*MQTTPublisher, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	opts := mqtt.NewClientOptions().
+		AddBroker(cfg.Broker).
+		SetClientID(cfg.ClientID).
+		SetUsername(cfg.Username).
+		SetPassword(cfg.Password).
+		SetAutoReconnect(true).
+		SetConnectTimeout(10 * time.Second)
+	// Subscribe on every connect, as a clean session forgets subscriptions
+	// when the broker connection drops.
+	if cfg.CommandTopic != "" {
+		opts.SetOnConnectHandler(func(c mqtt.Client) {
+			c.Subscribe(cfg.CommandTopic, byte(cfg.QoS), func(_ mqtt.Client, msg mqtt.Message) {
+				ctrl.Write(msg.Payload())
+			})
+		})
+	}
+
+	client := mqtt.NewClient(opts)
+	token := client.Connect()
+	if !token.WaitTimeout(15 * time.Second) {
+		client.Disconnect(0)
+		return nil, fmt.Errorf("failed to connect to %s: timed out", cfg.Broker)
+	}
+	if err := token.Error(); err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", cfg.Broker, err)
+	}
+
+	lines, unsubscribe := ctrl.Subscribe()
+	p := &MQTTPublisher{client: client, cfg: cfg, unsubscribe: unsubscribe, done: make(chan struct{})}
+	go p.run(lines, ctrl, decoder)
+	return p, nil
+}
+
+func (p *MQTTPublisher) run(lines <-chan SerialLine, ctrl MonitorControl, decoder func() Decoder) {
+	defer close(p.done)
+	for line := range lines {
+		port := ctrl.Status().Port
+		if !p.cfg.PerField {
+			p.publish(mqttTopic(p.cfg.TopicTemplate, port, "line"), line.Data)
+			continue
+		}
+		fields, err := decoder().Decode(line.Data)
+		if err != nil {
+			continue
+		}
+		for _, f := range fields {
+			p.publish(mqttTopic(p.cfg.TopicTemplate, port, f.Name), f.Value)
+		}
+	}
+}
+
+// publish sends without waiting for the broker, so a slow broker never holds
+// up the monitor.
+func (p *MQTTPublisher) publish(topic, payload string) {
+	p.client.Publish(topic, byte(p.cfg.QoS), p.cfg.Retain, payload)
+	p.published.Add(1)
+}
+
+// Published returns the number of messages sent so far.
+func (p *MQTTPublisher) Published() uint64 {
+	return p.published.Load()
+}
+
+// Connected reports whether the broker connection is up.
+func (p *MQTTPublisher) Connected() bool {
+	return p.client.IsConnectionOpen()
+}
+
+// Close stops publishing and disconnects from the broker.
+func (p *MQTTPublisher) Close() {
+	p.unsubscribe()
+	<-p.done
+	p.client.Disconnect(250)
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// startMQTT connects the MQTT publisher with the saved settings. It blocks
+// while connecting, so call it off the UI thread.
+func (ui *AppUI) startMQTT(cfg MQTTSettings) error {
+	p, err := StartMQTT(cfg, uiControl{ui}, func() Decoder {
+		ui.mu.Lock()
+		defer ui.mu.Unlock()
+		return ui.decoder
+	})
+	if err != nil {
+		return err
+	}
+	ui.mqttMu.Lock()
+	old := ui.mqtt
+	ui.mqtt = p
+	ui.mqttMu.Unlock()
+	if old != nil {
+		old.Close()
+	}
+	return nil
+}
+
+// stopMQTT disconnects the MQTT publisher if it is running.
+func (ui *AppUI) stopMQTT() {
+	ui.mqttMu.Lock()
+	p := ui.mqtt
+	ui.mqtt = nil
+	ui.mqttMu.Unlock()
+	if p != nil {
+		p.Close()
+	}
+}
+
+// showMQTTDialog configures MQTT publishing.
+func (ui *AppUI) showMQTTDialog() {
+	cfg := ui.settings.MQTT
+	enabledCheck := widget.NewCheck("Publish received lines", nil)
+	enabledCheck.SetChecked(cfg.Enabled)
+	brokerEntry := widget.NewEntry()
+	brokerEntry.SetPlaceHolder("tcp://localhost:1883")
+	brokerEntry.SetText(cfg.Broker)
+	clientIDEntry := widget.NewEntry()
+	clientIDEntry.SetText(cfg.ClientID)
+	userEntry := widget.NewEntry()
+	userEntry.SetText(cfg.Username)
+	passwordEntry := widget.NewPasswordEntry()
+	passwordEntry.SetText(cfg.Password)
+	topicEntry := widget.NewEntry()
+	topicEntry.SetText(cfg.TopicTemplate)
+	perFieldCheck := widget.NewCheck("One message per decoded field", nil)
+	perFieldCheck.SetChecked(cfg.PerField)
+	qosSelect := widget.NewSelect([]string{"0", "1", "2"}, nil)
+	qosSelect.SetSelected(strconv.Itoa(cfg.QoS))
+	retainCheck := widget.NewCheck("Retain", nil)
+	retainCheck.SetChecked(cfg.Retain)
+	commandEntry := widget.NewEntry()
+	commandEntry.SetPlaceHolder("optional, e.g. lab/arduino/cmd")
+	commandEntry.SetText(cfg.CommandTopic)
+
+	ui.mqttMu.Lock()
+	status := "Stopped"
+	if ui.mqtt != nil {
+		status = fmt.Sprintf("Running, %d messages published", ui.mqtt.Published())
+		if !ui.mqtt.Connected() {
+			status += " (reconnecting)"
+		}
+	}
+	ui.mqttMu.Unlock()
+
+	form := widget.NewForm(
+		widget.NewFormItem("Enabled", enabledCheck),
+		widget.NewFormItem("Broker", brokerEntry),
+		widget.NewFormItem("Client ID", clientIDEntry),
+		widget.NewFormItem("Username", userEntry),
+		widget.NewFormItem("Password", passwordEntry),
+		widget.NewFormItem("Topic", topicEntry),
+		widget.NewFormItem("", perFieldCheck),
+		widget.NewFormItem("QoS", container.NewHBox(qosSelect, retainCheck)),
+		widget.NewFormItem("Command Topic", commandEntry),
+		widget.NewFormItem("Status", widget.NewLabel(status)),
+	)
+	help := widget.NewLabel("{port} and {field} in topics are replaced by the port name\n" +
+		"and the decoded field name (\"line\" for whole lines).")
+
+	dialog.ShowCustomConfirm("MQTT", "Apply", "Cancel", container.NewVBox(form, help), func(ok bool) {
+		if !ok {
+			return
+		}
+		qos, _ := strconv.Atoi(qosSelect.Selected)
+		cfg := MQTTSettings{
+			Enabled:       enabledCheck.Checked,
+			Broker:        strings.TrimSpace(brokerEntry.Text),
+			ClientID:      strings.TrimSpace(clientIDEntry.Text),
+			Username:      userEntry.Text,
+			Password:      passwordEntry.Text,
+			TopicTemplate: strings.TrimSpace(topicEntry.Text),
+			PerField:      perFieldCheck.Checked,
+			QoS:           qos,
+			Retain:        retainCheck.Checked,
+			CommandTopic:  strings.TrimSpace(commandEntry.Text),
+		}
+		if cfg.Enabled {
+			if err := cfg.validate(); err != nil {
+				dialog.ShowError(err, ui.window)
+				return
+			}
+		}
+		ui.settings.MQTT = cfg
+		ui.saveSettings()
+
+		ui.stopMQTT()
+		if !cfg.Enabled {
+			return
+		}
+		go func() {
+			if err := ui.startMQTT(cfg); err != nil {
+				fyne.Do(func() { dialog.ShowError(err, ui.window) })
+			}
+		}()
+	}, ui.window)
+}
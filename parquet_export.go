@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/compress"
+)
+
+// Parquet compression codecs offered in the export dialog.
+var parquetCompressions = map[string]compress.Codec{
+	"Snappy": &parquet.Snappy,
+	"Gzip":   &parquet.Gzip,
+	"Zstd":   &parquet.Zstd,
+	"None":   &parquet.Uncompressed,
+}
+
+var parquetCompressionNames = []string{"Snappy", "Gzip", "Zstd", "None"}
+
+// ParquetOptions configures the Parquet exporter.
+type ParquetOptions struct {
+	Compression string // one of parquetCompressionNames; Snappy if empty
+}
+
+// parquetExporter writes a Parquet file with one optional column per exported
+// column, UTF-8 unless a numeric type was inferred; empty cells are stored as
+// nulls.
+type parquetExporter struct{}
+
+func (parquetExporter) Format() string       { return FormatParquet }
+func (parquetExporter) Extensions() []string { return []string{".parquet"} }
+
+func (parquetExporter) Write(path string, table *ExportTable, opts ExportOptions) error {
+	width := table.width()
+	if width == 0 {
+		return fmt.Errorf("no columns to export")
+	}
+
+	root := parquetRow{Group: parquet.Group{}}
+	seen := make(map[string]bool)
+	for i := range width {
+		name := parquetColumnName(table.columnName(i))
+		for base, n := name, 2; seen[name]; n++ {
+			name = fmt.Sprintf("%s_%d", base, n)
+		}
+		seen[name] = true
+		var node parquet.Node
+		switch table.columnType(i) {
+		case ColumnInt:
+			node = parquet.Leaf(parquet.Int64Type)
+		case ColumnFloat:
+			node = parquet.Leaf(parquet.DoubleType)
+		default:
+			node = parquet.String()
+		}
+		node = parquet.Optional(node)
+		root.Group[name] = node
+		root.fields = append(root.fields, parquetField{Node: node, name: name})
+	}
+
+	codec, ok := parquetCompressions[opts.Parquet.Compression]
+	if !ok {
+		codec = &parquet.Snappy
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer f.Close()
+
+	pw := parquet.NewWriter(f, parquet.NewSchema("serial", root), parquet.Compression(codec))
+	for i, record := range table.Rows {
+		row := make(parquet.Row, width)
+		for j := range row {
+			v := parquetValue(record, j, table.columnType(j))
+			if v.IsNull() {
+				row[j] = v.Level(0, 0, j)
+			} else {
+				row[j] = v.Level(0, 1, j) // defined optional value
+			}
+		}
+		if _, err := pw.WriteRows([]parquet.Row{row}); err != nil {
+			return fmt.Errorf("failed to write row %d: %w", i+1, err)
+		}
+	}
+
+	if err := pw.Close(); err != nil {
+		return fmt.Errorf("failed to finish parquet file: %w", err)
+	}
+	return nil
+}
+
+// parquetValue converts cell j of a record to a value of the column's type,
+// or null when it is empty or doesn't parse.
+func parquetValue(record []string, j int, typ ColumnType) parquet.Value {
+	if j >= len(record) {
+		return parquet.NullValue()
+	}
+	v := record[j]
+	if typ == ColumnString {
+		if v == "" {
+			return parquet.NullValue()
+		}
+		return parquet.ByteArrayValue([]byte(v))
+	}
+	v = strings.TrimSpace(v)
+	switch typ {
+	case ColumnInt:
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return parquet.Int64Value(n)
+		}
+	case ColumnFloat:
+		if x, err := strconv.ParseFloat(v, 64); err == nil {
+			return parquet.DoubleValue(x)
+		}
+	}
+	return parquet.NullValue()
+}
+
+// parquetRow is the schema's root group. parquet.Group orders its columns by
+// name, so the fields are listed explicitly to keep the table's order.
+type parquetRow struct {
+	parquet.Group
+	fields []parquet.Field
+}
+
+func (r parquetRow) Fields() []parquet.Field { return r.fields }
+
+// parquetField is a named column of parquetRow. Rows are written as values,
+// not Go structs, so Value is never used.
+type parquetField struct {
+	parquet.Node
+	name string
+}
+
+func (f parquetField) Name() string                      { return f.name }
+func (f parquetField) Value(reflect.Value) reflect.Value { return reflect.Value{} }
+
+// parquetColumnName makes a header usable in the schema, which cannot contain
+// separators such as commas or equals signs.
+func parquetColumnName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+			return r
+		}
+		return '_'
+	}, strings.TrimSpace(name))
+	if name == "" || unicode.IsDigit(rune(name[0])) {
+		name = "c" + name
+	}
+	return name
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+func TestParquetExportKeepsColumnOrderAndTypes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.parquet")
+	table := &ExportTable{
+		Header: []string{"zeta", "alpha", "name"},
+		Rows: [][]string{
+			{"1", "2.5", "a"},
+			{"", " 3.25", ""},
+		},
+		Types: []ColumnType{ColumnInt, ColumnFloat, ColumnString},
+	}
+	for _, compression := range parquetCompressionNames {
+		if err := (parquetExporter{}).Write(path, table, ExportOptions{Parquet: ParquetOptions{Compression: compression}}); err != nil {
+			t.Fatalf("%s: %v", compression, err)
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		info, _ := f.Stat()
+		pf, err := parquet.OpenFile(f, info.Size())
+		if err != nil {
+			t.Fatalf("%s: %v", compression, err)
+		}
+		var names []string
+		for _, field := range pf.Schema().Fields() {
+			names = append(names, field.Name())
+		}
+		if len(names) != 3 || names[0] != "zeta" || names[1] != "alpha" || names[2] != "name" {
+			t.Errorf("%s: columns %v, want table order", compression, names)
+		}
+
+		rows := make([]parquet.Row, 2)
+		n, _ := pf.RowGroups()[0].Rows().ReadRows(rows)
+		f.Close()
+		if n != 2 {
+			t.Fatalf("%s: read %d rows, want 2", compression, n)
+		}
+		if rows[0][0].Int64() != 1 || rows[0][1].Double() != 2.5 || rows[0][2].String() != "a" {
+			t.Errorf("%s: first row %v", compression, rows[0])
+		}
+		if !rows[1][0].IsNull() || rows[1][1].Double() != 3.25 || !rows[1][2].IsNull() {
+			t.Errorf("%s: second row %v, want nulls for empty cells", compression, rows[1])
+		}
+	}
+}
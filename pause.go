@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/theme"
+)
+
+// visibleLocked returns the lines the output list shows: the frozen snapshot
+// while paused, the live buffer otherwise. Must be called with ui.mu held.
+func (ui *AppUI) visibleLocked() ([]SerialLine, []string) {
+	if ui.paused {
+		return ui.frozenLines, ui.frozenDisplay
+	}
+	return ui.lines, ui.displayLines
+}
+
+// togglePause freezes the output view while capture continues, or resumes
+// and jumps to the newest line.
+func (ui *AppUI) togglePause() {
+	ui.mu.Lock()
+	ui.paused = !ui.paused
+	paused := ui.paused
+	// Appending and trimming never modify existing elements, so the slices
+	// themselves are a stable snapshot.
+	ui.frozenLines, ui.frozenDisplay = nil, nil
+	if paused {
+		ui.frozenLines, ui.frozenDisplay = ui.lines, ui.displayLines
+	}
+	ui.pausedCount = 0
+	tableMode := ui.tableMode
+	if !paused && tableMode {
+		ui.tableView.catchUpLocked()
+	}
+	ui.mu.Unlock()
+
+	if paused {
+		ui.pauseBtn.SetText("Resume")
+		ui.pauseBtn.SetIcon(theme.MediaPlayIcon())
+		ui.updatePausedLabel()
+		ui.pausedLabel.Show()
+		return
+	}
+	ui.pauseBtn.SetText("Pause")
+	ui.pauseBtn.SetIcon(theme.MediaPauseIcon())
+	ui.pausedLabel.Hide()
+	ui.refreshOutput()
+	ui.output.ScrollToBottom()
+	if tableMode {
+		ui.tableView.table.ScrollToBottom()
+	}
+}
+
+// updatePausedLabel shows how many lines arrived since pausing.
+func (ui *AppUI) updatePausedLabel() {
+	ui.mu.Lock()
+	n := ui.pausedCount
+	ui.mu.Unlock()
+	ui.pausedLabel.SetText(fmt.Sprintf("Paused: %d new lines", n))
+}
+
+// scheduleRefresh queues a redraw of the output, or of the paused counter,
+// unless one is already pending, so bursts of lines cost one redraw per frame.
+// Safe to call from any goroutine.
+func (ui *AppUI) scheduleRefresh() {
+	if !ui.refreshPending.CompareAndSwap(false, true) {
+		return
+	}
+	fyne.Do(func() {
+		ui.refreshPending.Store(false)
+		ui.mu.Lock()
+		paused := ui.paused
+		ui.mu.Unlock()
+		if paused {
+			ui.updatePausedLabel()
+			return
+		}
+		ui.refreshOutput()
+	})
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"go.bug.st/serial/enumerator"
+)
+
+// Profile is a named set of connection and display settings for one device.
+// An empty Encoding, Backpressure, Invisibles or TimestampMode leaves the
+// current choice in place, so profiles saved before they existed still apply.
+type Profile struct {
+	Name           string          `json:"name"`
+	BaudRate       int             `json:"baudRate"`
+	Framing        Framing         `json:"framing"`
+	LineEnding     string          `json:"lineEnding"`               // default for Send File, from lineEndingNames
+	Decoder        string          `json:"decoder"`                  // decoder name
+	Encoding       string          `json:"encoding,omitempty"`       // text encoding, from encodingNames
+	KeepCR         bool            `json:"keepCR,omitempty"`         // keep '\r' in received lines
+	Backpressure   string          `json:"backpressure,omitempty"`   // from backpressurePolicies
+	Invisibles     string          `json:"invisibles,omitempty"`     // from invisiblesModes
+	TimestampMode  string          `json:"timestampMode,omitempty"`  // from displayTimestampModes
+	Timestamps     bool            `json:"timestamps,omitempty"`     // show timestamps; applied only with TimestampMode
+	HeaderTemplate *HeaderTemplate `json:"headerTemplate,omitempty"` // nil for decoded field names
+	USBSerial      string          `json:"usbSerial,omitempty"`      // apply automatically when this USB serial number appears
+}
+
+// profileFile is the on-disk layout of exported profiles, so one file can
+// carry a single device or a team's whole set.
+type profileFile struct {
+	Profiles []Profile `json:"profiles"`
+}
+
+// validate checks that a profile, e.g. one read from a shared file, can be applied.
+func (p Profile) validate() error {
+	if strings.TrimSpace(p.Name) == "" {
+		return fmt.Errorf("profile has no name")
+	}
+	if p.BaudRate <= 0 {
+		return fmt.Errorf("profile %q: invalid baud rate %d", p.Name, p.BaudRate)
+	}
+	if _, err := p.Framing.mode(p.BaudRate); err != nil {
+		return fmt.Errorf("profile %q: %w", p.Name, err)
+	}
+	for _, c := range []struct {
+		what, value string
+		allowed     []string
+	}{
+		{"line ending", p.LineEnding, lineEndingNames},
+		{"decoder", p.Decoder, decoderNames()},
+		{"encoding", p.Encoding, encodingNames},
+		{"backpressure policy", p.Backpressure, backpressurePolicies},
+		{"invisibles mode", p.Invisibles, invisiblesModes},
+		{"timestamp mode", p.TimestampMode, displayTimestampModes},
+	} {
+		if c.value != "" && !slices.Contains(c.allowed, c.value) {
+			return fmt.Errorf("profile %q: unknown %s %q", p.Name, c.what, c.value)
+		}
+	}
+	if p.HeaderTemplate != nil {
+		if err := p.HeaderTemplate.validate(); err != nil {
+			return fmt.Errorf("profile %q: %w", p.Name, err)
+		}
+	}
+	return nil
+}
+
+// ExportProfiles writes profiles to a JSON file for sharing.
+func ExportProfiles(path string, profiles []Profile) error {
+	data, err := json.MarshalIndent(profileFile{Profiles: profiles}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal profiles: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write profiles: %w", err)
+	}
+	return nil
+}
+
+// ImportProfiles reads profiles written by ExportProfiles.
+func ImportProfiles(path string) ([]Profile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read profiles: %w", err)
+	}
+	var f profileFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse profiles: %w", err)
+	}
+	if len(f.Profiles) == 0 {
+		return nil, fmt.Errorf("no profiles in %s", path)
+	}
+	for _, p := range f.Profiles {
+		if err := p.validate(); err != nil {
+			return nil, err
+		}
+	}
+	return f.Profiles, nil
+}
+
+// mergeProfiles adds incoming profiles to existing ones, replacing any with
+// the same name.
+func mergeProfiles(existing, incoming []Profile) []Profile {
+	merged := append([]Profile(nil), existing...)
+	for _, p := range incoming {
+		replaced := false
+		for i := range merged {
+			if merged[i].Name == p.Name {
+				merged[i] = p
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			merged = append(merged, p)
+		}
+	}
+	return merged
+}
+
+// USBPort is a detected USB serial port.
+type USBPort struct {
+	Name         string
+	SerialNumber string
+	Product      string
+}
+
+// usbPorts lists detected USB serial ports that report a serial number.
+func usbPorts() []USBPort {
+	details, err := enumerator.GetDetailedPortsList()
+	if err != nil {
+		return nil
+	}
+	var ports []USBPort
+	for _, d := range details {
+		if d.IsUSB && d.SerialNumber != "" {
+			ports = append(ports, USBPort{Name: d.Name, SerialNumber: d.SerialNumber, Product: d.Product})
+		}
+	}
+	return ports
+}
+
+// addedPorts returns the ports in after that weren't in before.
+func addedPorts(before, after []USBPort) []USBPort {
+	var added []USBPort
+	for _, p := range after {
+		if !slices.Contains(before, p) {
+			added = append(added, p)
+		}
+	}
+	return added
+}
+
+// matchProfile finds the first profile bound to one of the detected USB
+// ports, returning the profile and the port name.
+func matchProfile(profiles []Profile, ports []USBPort) (Profile, string, bool) {
+	for _, p := range profiles {
+		if p.USBSerial == "" {
+			continue
+		}
+		for _, port := range ports {
+			if strings.EqualFold(port.SerialNumber, p.USBSerial) {
+				return p, port.Name, true
+			}
+		}
+	}
+	return Profile{}, "", false
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestImportProfilesRoundTrip(t *testing.T) {
+	p := Profile{
+		Name:           "sensor",
+		BaudRate:       115200,
+		Framing:        defaultFraming,
+		LineEnding:     "CRLF",
+		Decoder:        csvDecoder{}.Name(),
+		Encoding:       EncodingLatin1,
+		KeepCR:         true,
+		Backpressure:   BackpressureSpill,
+		Invisibles:     InvisiblesEscapes,
+		TimestampMode:  DisplayDelta,
+		Timestamps:     true,
+		HeaderTemplate: &HeaderTemplate{Name: "t", Columns: []TemplateColumn{{Name: "temp", Unit: "C", Type: ColumnTypeFloat}}},
+		USBSerial:      "A1B2",
+	}
+	path := filepath.Join(t.TempDir(), "profiles.json")
+	if err := ExportProfiles(path, []Profile{p}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ImportProfiles(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || !reflect.DeepEqual(got[0], p) {
+		t.Errorf("got %+v, want %+v", got, p)
+	}
+}
+
+func TestImportProfilesRejectsUnknownValues(t *testing.T) {
+	framing, err := json.Marshal(defaultFraming)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		field, want string
+	}{
+		{`"decoder": "xml"`, `unknown decoder "xml"`},
+		{`"encoding": "EBCDIC"`, `unknown encoding "EBCDIC"`},
+		{`"backpressure": "panic"`, `unknown backpressure policy "panic"`},
+		{`"invisibles": "sparkles"`, `unknown invisibles mode "sparkles"`},
+		{`"timestampMode": "sundial"`, `unknown timestamp mode "sundial"`},
+		{`"headerTemplate": {"name": "t", "columns": [{"name": "a", "type": "date"}]}`, `unknown type "date"`},
+		{`"headerTemplate": {"name": "t", "columns": []}`, `has no columns`},
+	} {
+		path := filepath.Join(t.TempDir(), "profiles.json")
+		data := `{"profiles": [{"name": "p", "baudRate": 9600, "framing": ` + string(framing) + `, ` + tc.field + `}]}`
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ImportProfiles(path); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: error %v, want one containing %q", tc.field, err, tc.want)
+		}
+	}
+}
+
+func TestAddedPorts(t *testing.T) {
+	a := USBPort{Name: "/dev/ttyUSB0", SerialNumber: "A"}
+	b := USBPort{Name: "/dev/ttyUSB1", SerialNumber: "B"}
+	// The same device on a new port name counts as plugged in again.
+	moved := USBPort{Name: "/dev/ttyUSB2", SerialNumber: "A"}
+
+	if got := addedPorts([]USBPort{a}, []USBPort{a, b}); !reflect.DeepEqual(got, []USBPort{b}) {
+		t.Errorf("plugged b: got %v", got)
+	}
+	if got := addedPorts([]USBPort{a, b}, []USBPort{a}); got != nil {
+		t.Errorf("unplugged b: got %v", got)
+	}
+	if got := addedPorts([]USBPort{a}, []USBPort{moved}); !reflect.DeepEqual(got, []USBPort{moved}) {
+		t.Errorf("moved a: got %v", got)
+	}
+}
@@ -0,0 +1,322 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+)
+
+// refreshProfiles reloads the toolbar profile choices.
+func (ui *AppUI) refreshProfiles() {
+	names := make([]string, len(ui.settings.Profiles))
+	for i, p := range ui.settings.Profiles {
+		names[i] = p.Name
+	}
+	ui.profileSelect.Options = names
+	if !slices.Contains(names, ui.profileSelect.Selected) {
+		ui.profileSelect.ClearSelected()
+	}
+	ui.profileSelect.Refresh()
+}
+
+// selectProfile shows a profile as chosen in the toolbar without applying it.
+func (ui *AppUI) selectProfile(name string) {
+	onChanged := ui.profileSelect.OnChanged
+	ui.profileSelect.OnChanged = nil
+	ui.profileSelect.SetSelected(name)
+	ui.profileSelect.OnChanged = onChanged
+}
+
+// currentProfile captures the current connection and display settings.
+func (ui *AppUI) currentProfile(name string) Profile {
+	baud, _ := strconv.Atoi(ui.baudSelect.Selected)
+	ui.mu.Lock()
+	decoder := ui.decoder.Name()
+	invisibles, timestampMode, timestamps := ui.invisibles, ui.timestampMode, ui.showTimestamp
+	ui.mu.Unlock()
+
+	var template *HeaderTemplate
+	if t, ok := templateByName(ui.savedTemplates, ui.tableView.headerSelect.Selected); ok {
+		template = &t
+	}
+	return Profile{
+		Name:           name,
+		BaudRate:       baud,
+		Framing:        ui.framing(),
+		LineEnding:     ui.lineEnding,
+		Decoder:        decoder,
+		Encoding:       ui.encodingSel.Selected,
+		KeepCR:         ui.keepCRChk.Checked,
+		Backpressure:   ui.settings.Connection.Backpressure,
+		Invisibles:     invisibles,
+		TimestampMode:  timestampMode,
+		Timestamps:     timestamps,
+		HeaderTemplate: template,
+	}
+}
+
+// applyProfile switches the controls to a profile's settings. A header
+// template the profile brings along is added to the saved templates.
+func (ui *AppUI) applyProfile(p Profile) {
+	baud := strconv.Itoa(p.BaudRate)
+	if !slices.Contains(ui.baudSelect.Options, baud) {
+		ui.baudSelect.Options = append(ui.baudSelect.Options, baud)
+	}
+	ui.baudSelect.SetSelected(baud)
+	ui.setFraming(p.Framing)
+	if p.LineEnding != "" {
+		ui.lineEnding = p.LineEnding
+	}
+	if p.Decoder != "" {
+		ui.decoderSelect.SetSelected(decoderByName(p.Decoder).Name())
+	}
+	if slices.Contains(encodingNames, p.Encoding) {
+		ui.encodingSel.SetSelected(p.Encoding)
+	}
+	ui.keepCRChk.SetChecked(p.KeepCR)
+	if slices.Contains(backpressurePolicies, p.Backpressure) {
+		ui.settings.Connection.Backpressure = p.Backpressure
+		ui.serial.SetBackpressure(p.Backpressure)
+	}
+	if slices.Contains(invisiblesModes, p.Invisibles) {
+		ui.invisiblesSel.SetSelected(p.Invisibles)
+	}
+	if slices.Contains(displayTimestampModes, p.TimestampMode) {
+		ui.timestampSel.SetSelected(p.TimestampMode)
+		ui.timestampChk.SetChecked(p.Timestamps)
+	}
+
+	ui.headerTemplate = ""
+	if t := p.HeaderTemplate; t != nil {
+		ui.headerTemplate = t.Name
+		if existing, ok := templateByName(ui.savedTemplates, t.Name); !ok || !reflect.DeepEqual(existing, *t) {
+			ui.savedTemplates = mergeTemplates(ui.savedTemplates, []HeaderTemplate{*t})
+			ui.templatesChanged()
+		}
+		ui.tableView.headerSelect.SetSelected(t.Name)
+	} else {
+		ui.tableView.headerSelect.SetSelected(tableHeaderFieldNames)
+	}
+	ui.selectProfile(p.Name)
+}
+
+// autoApplyProfile applies the first profile bound to a connected USB serial
+// number and selects its port. It does nothing while connected.
+func (ui *AppUI) autoApplyProfile() bool {
+	return ui.applyProfileFor(usbPorts())
+}
+
+// applyProfileFor applies the first profile bound to one of ports and selects
+// its port. It does nothing while connected.
+func (ui *AppUI) applyProfileFor(ports []USBPort) bool {
+	if ui.connected.Load() {
+		return false
+	}
+	p, port, ok := matchProfile(ui.settings.Profiles, ports)
+	if !ok {
+		return false
+	}
+	if slices.Contains(ui.portSelect.Options, port) {
+		ui.portSelect.SetSelected(port)
+	}
+	ui.applyProfile(p)
+	return true
+}
+
+// watchPorts checks for plugged and unplugged ports every two seconds for the
+// life of the app. It updates the port list and applies the profile bound to
+// a newly plugged USB device, leaving profiles alone when other ports change.
+func (ui *AppUI) watchPorts() {
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+	names, usb := ui.serial.AvailablePorts(), usbPorts()
+	for range ticker.C {
+		nowNames, nowUSB := ui.serial.AvailablePorts(), usbPorts()
+		added := addedPorts(usb, nowUSB)
+		if slices.Equal(names, nowNames) && len(added) == 0 {
+			continue
+		}
+		names, usb = nowNames, nowUSB
+		fyne.Do(func() {
+			ui.refreshPorts()
+			if len(added) > 0 {
+				ui.applyProfileFor(added)
+			}
+		})
+	}
+}
+
+// showProfilesDialog manages saved profiles: save, delete, bind to a USB
+// device, and share as files.
+func (ui *AppUI) showProfilesDialog() {
+	selected := -1
+	details := widget.NewLabel("")
+	details.Wrapping = fyne.TextWrapWord
+
+	list := widget.NewList(
+		func() int { return len(ui.settings.Profiles) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			if id < len(ui.settings.Profiles) {
+				obj.(*widget.Label).SetText(ui.settings.Profiles[id].Name)
+			}
+		},
+	)
+	showDetails := func() {
+		if selected < 0 || selected >= len(ui.settings.Profiles) {
+			details.SetText("")
+			return
+		}
+		p := ui.settings.Profiles[selected]
+		lines := []string{
+			fmt.Sprintf("%d baud, %s", p.BaudRate, p.Framing),
+			"Line ending: " + p.LineEnding,
+			"Decoder: " + p.Decoder,
+		}
+		if p.HeaderTemplate != nil {
+			lines = append(lines, "Header: "+p.HeaderTemplate.Name)
+		}
+		if p.USBSerial != "" {
+			lines = append(lines, "USB serial: "+p.USBSerial)
+		}
+		details.SetText(strings.Join(lines, "\n"))
+	}
+	list.OnSelected = func(id widget.ListItemID) {
+		selected = id
+		showDetails()
+	}
+
+	changed := func() {
+		ui.saveSettings()
+		ui.refreshProfiles()
+		list.Refresh()
+		showDetails()
+	}
+
+	saveBtn := widget.NewButton("Save Current As...", func() {
+		nameEntry := widget.NewEntry()
+		nameEntry.SetText(ui.profileSelect.Selected)
+		dialog.ShowForm("Save Profile", "Save", "Cancel",
+			[]*widget.FormItem{widget.NewFormItem("Name", nameEntry)},
+			func(ok bool) {
+				name := strings.TrimSpace(nameEntry.Text)
+				if !ok || name == "" {
+					return
+				}
+				p := ui.currentProfile(name)
+				// Keep an existing USB binding when overwriting a profile.
+				for _, old := range ui.settings.Profiles {
+					if old.Name == name {
+						p.USBSerial = old.USBSerial
+					}
+				}
+				if err := p.validate(); err != nil {
+					dialog.ShowError(err, ui.window)
+					return
+				}
+				ui.settings.Profiles = mergeProfiles(ui.settings.Profiles, []Profile{p})
+				ui.selectProfile(name)
+				changed()
+			}, ui.window)
+	})
+
+	deleteBtn := widget.NewButton("Delete", func() {
+		if selected < 0 || selected >= len(ui.settings.Profiles) {
+			return
+		}
+		ui.settings.Profiles = slices.Delete(ui.settings.Profiles, selected, selected+1)
+		selected = -1
+		list.UnselectAll()
+		changed()
+	})
+
+	bindBtn := widget.NewButton("Bind USB Device...", func() {
+		if selected < 0 || selected >= len(ui.settings.Profiles) {
+			dialog.ShowInformation("Bind USB Device", "Select a profile first.", ui.window)
+			return
+		}
+		options := []string{"None"}
+		serials := []string{""}
+		for _, port := range usbPorts() {
+			options = append(options, fmt.Sprintf("%s  %s (%s)", port.Name, port.SerialNumber, port.Product))
+			serials = append(serials, port.SerialNumber)
+		}
+		deviceSelect := widget.NewSelect(options, nil)
+		deviceSelect.SetSelected(options[0])
+		serialEntry := widget.NewEntry()
+		serialEntry.SetText(ui.settings.Profiles[selected].USBSerial)
+		deviceSelect.OnChanged = func(string) {
+			serialEntry.SetText(serials[slices.Index(options, deviceSelect.Selected)])
+		}
+		dialog.ShowForm("Bind USB Device", "Bind", "Cancel",
+			[]*widget.FormItem{
+				widget.NewFormItem("Detected", deviceSelect),
+				widget.NewFormItem("Serial Number", serialEntry),
+			},
+			func(ok bool) {
+				if !ok || selected < 0 || selected >= len(ui.settings.Profiles) {
+					return
+				}
+				ui.settings.Profiles[selected].USBSerial = strings.TrimSpace(serialEntry.Text)
+				changed()
+			}, ui.window)
+	})
+
+	exportBtn := widget.NewButton("Export...", func() {
+		profiles := ui.settings.Profiles
+		name := "profiles.json"
+		if selected >= 0 && selected < len(profiles) {
+			profiles = profiles[selected : selected+1]
+			name = profiles[0].Name + ".json"
+		}
+		if len(profiles) == 0 {
+			return
+		}
+		fd := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+			if err != nil || writer == nil {
+				return
+			}
+			writer.Close()
+			if err := ExportProfiles(localPath(writer.URI()), profiles); err != nil {
+				dialog.ShowError(err, ui.window)
+			}
+		}, ui.window)
+		fd.SetFileName(name)
+		fd.SetFilter(storage.NewExtensionFileFilter([]string{".json"}))
+		fd.Show()
+	})
+
+	importBtn := widget.NewButton("Import...", func() {
+		fd := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil || reader == nil {
+				return
+			}
+			reader.Close()
+			profiles, err := ImportProfiles(localPath(reader.URI()))
+			if err != nil {
+				dialog.ShowError(err, ui.window)
+				return
+			}
+			ui.settings.Profiles = mergeProfiles(ui.settings.Profiles, profiles)
+			changed()
+			dialog.ShowInformation("Import Profiles", fmt.Sprintf("Imported %d profiles.", len(profiles)), ui.window)
+		}, ui.window)
+		fd.SetFilter(storage.NewExtensionFileFilter([]string{".json"}))
+		fd.Show()
+	})
+
+	buttons := container.NewVBox(saveBtn, deleteBtn, bindBtn, exportBtn, importBtn)
+	listScroll := container.NewVScroll(list)
+	listScroll.SetMinSize(fyne.NewSize(200, 260))
+	content := container.NewBorder(nil, details, nil, buttons, listScroll)
+	dialog.ShowCustom("Connection Profiles", "Close", content, ui.window)
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Pacing modes for SendFile.
+const (
+	PacingNone   = "None"
+	PacingEcho   = "Wait for echo"
+	PacingPrompt = "Wait for prompt"
+)
+
+var pacingModes = []string{PacingNone, PacingEcho, PacingPrompt}
+
+// lineEndingNames lists the selectable line endings in display order.
+var lineEndingNames = []string{"LF", "CR", "CRLF", "None"}
+
+// lineEnding returns the bytes for a named line ending.
+func lineEnding(name string) string {
+	switch name {
+	case "LF":
+		return "\n"
+	case "CR":
+		return "\r"
+	case "CRLF":
+		return "\r\n"
+	}
+	return ""
+}
+
+// SendFileOptions configures how a file is streamed to the serial port.
+type SendFileOptions struct {
+	FilePath    string
+	Binary      bool          // send bytes untouched instead of line by line
+	LineEnding  string        // name from lineEndingNames, applied in text mode
+	ChunkSize   int           // max bytes per write
+	Delay       time.Duration // pause after each line (text) or chunk (binary)
+	Pacing      string        // one of pacingModes
+	Prompt      string        // text to wait for when Pacing is PacingPrompt
+	WaitTimeout time.Duration // how long to wait for an echo or prompt
+}
+
+// SendFile streams a file through the serial port according to opts.
+// progress is called after every write with the bytes sent so far and the total.
+// Closing cancel aborts the transfer.
+func SendFile(sm *SerialManager, opts SendFileOptions, progress func(sent, total int), cancel <-chan struct{}) error {
+	data, err := os.ReadFile(opts.FilePath)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+	if len(data) == 0 {
+		return fmt.Errorf("file is empty")
+	}
+	if opts.ChunkSize <= 0 {
+		opts.ChunkSize = 64
+	}
+
+	units := splitSendUnits(data, opts)
+	total := 0
+	for _, u := range units {
+		total += len(u)
+	}
+
+	var tap <-chan []byte
+	if opts.Pacing != PacingNone && opts.Pacing != "" {
+		ch, unsubscribe := sm.Tap()
+		defer unsubscribe()
+		tap = ch
+	}
+
+	sent := 0
+	progress(sent, total)
+	for i, unit := range units {
+		drainTap(tap)
+
+		for off := 0; off < len(unit); off += opts.ChunkSize {
+			select {
+			case <-cancel:
+				return fmt.Errorf("send cancelled")
+			default:
+			}
+
+			end := min(off+opts.ChunkSize, len(unit))
+			if _, err := sm.Write(unit[off:end]); err != nil {
+				return fmt.Errorf("failed to write: %w", err)
+			}
+			sent += end - off
+			progress(sent, total)
+		}
+
+		switch opts.Pacing {
+		case PacingEcho:
+			// Devices echo a line without its ending, or with their own;
+			// binary chunks must come back byte for byte.
+			want := unit
+			if !opts.Binary {
+				want = bytes.TrimRight(unit, "\r\n")
+			}
+			if err := waitForBytes(tap, want, opts.WaitTimeout, cancel); err != nil {
+				return fmt.Errorf("no echo for unit %d: %w", i+1, err)
+			}
+		case PacingPrompt:
+			if err := waitForBytes(tap, []byte(opts.Prompt), opts.WaitTimeout, cancel); err != nil {
+				return fmt.Errorf("no prompt after unit %d: %w", i+1, err)
+			}
+		}
+
+		if opts.Delay > 0 && i < len(units)-1 {
+			select {
+			case <-cancel:
+				return fmt.Errorf("send cancelled")
+			case <-time.After(opts.Delay):
+			}
+		}
+	}
+	return nil
+}
+
+// splitSendUnits breaks file data into the units that are paced individually:
+// lines with the chosen ending in text mode, fixed-size chunks in binary mode.
+func splitSendUnits(data []byte, opts SendFileOptions) [][]byte {
+	var units [][]byte
+	if opts.Binary {
+		for off := 0; off < len(data); off += opts.ChunkSize {
+			units = append(units, data[off:min(off+opts.ChunkSize, len(data))])
+		}
+		return units
+	}
+
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	text = strings.TrimSuffix(text, "\n")
+	ending := lineEnding(opts.LineEnding)
+	for _, line := range strings.Split(text, "\n") {
+		units = append(units, []byte(line+ending))
+	}
+	return units
+}
+
+// drainTap discards any bytes already waiting on the tap.
+func drainTap(tap <-chan []byte) {
+	for {
+		select {
+		case <-tap:
+		default:
+			return
+		}
+	}
+}
+
+// waitForBytes reads from the tap until want has been seen, the timeout expires,
+// or cancel is closed.
+func waitForBytes(tap <-chan []byte, want []byte, timeout time.Duration, cancel <-chan struct{}) error {
+	if len(want) == 0 {
+		return nil
+	}
+	if timeout <= 0 {
+		timeout = time.Second
+	}
+	deadline := time.After(timeout)
+
+	var seen []byte
+	for {
+		select {
+		case chunk := <-tap:
+			seen = append(seen, chunk...)
+			if bytes.Contains(seen, want) {
+				return nil
+			}
+		case <-deadline:
+			return fmt.Errorf("timed out after %v", timeout)
+		case <-cancel:
+			return fmt.Errorf("send cancelled")
+		}
+	}
+}
@@ -0,0 +1,83 @@
+//go:build linux
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/creack/pty"
+)
+
+// echoDevice opens a pty pair and answers everything written to the slave
+// with reply(data). It returns the slave's device path.
+func echoDevice(t *testing.T, reply func([]byte) []byte) string {
+	t.Helper()
+	master, slave, err := pty.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if master, err = pollable(master); err != nil {
+		t.Fatal(err)
+	}
+	if err := makeRaw(slave); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		master.Close()
+		slave.Close()
+	})
+	go func() {
+		buf := make([]byte, 256)
+		for {
+			n, err := master.Read(buf)
+			if err != nil {
+				return
+			}
+			master.Write(reply(buf[:n]))
+		}
+	}()
+	return slave.Name()
+}
+
+func TestSendFileBinaryEchoComparesWholeChunk(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.bin")
+	if err := os.WriteFile(path, []byte("ab\r\ncd\r\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	opts := SendFileOptions{FilePath: path, Binary: true, ChunkSize: 4, Pacing: PacingEcho, WaitTimeout: 300 * time.Millisecond}
+
+	for _, tc := range []struct {
+		name    string
+		reply   func([]byte) []byte
+		wantErr bool
+	}{
+		{"full echo", func(b []byte) []byte { return bytes.Clone(b) }, false},
+		// "\r\n" is data in a binary chunk, so an echo without it is short.
+		{"echo missing CR LF", func(b []byte) []byte {
+			return bytes.ReplaceAll(bytes.ReplaceAll(b, []byte("\r"), nil), []byte("\n"), nil)
+		}, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			sm := NewSerialManager()
+			if err := sm.Connect(echoDevice(t, tc.reply), 115200, defaultFraming); err != nil {
+				t.Fatal(err)
+			}
+			defer sm.Disconnect()
+			sm.StartReading()
+
+			err := SendFile(sm, opts, func(int, int) {}, nil)
+			if tc.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "no echo for unit 1") {
+					t.Errorf("error %v, want no echo for unit 1", err)
+				}
+			} else if err != nil {
+				t.Errorf("error %v", err)
+			}
+		})
+	}
+}
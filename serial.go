@@ -2,32 +2,141 @@ package main
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
 	"sync"
 	"time"
 
 	"go.bug.st/serial"
 )
 
+var (
+	errReadTimeout       = errors.New("timed out")
+	errTransferCancelled = errors.New("transfer cancelled")
+)
+
 // SerialManager handles serial port connection and data reading.
 type SerialManager struct {
-	mu       sync.Mutex
-	port     serial.Port
-	portName string
-	baudRate int
-	running  bool
-	stopCh   chan struct{}
-	doneCh   chan struct{} // signals when the reader goroutine has exited
+	mu        sync.Mutex
+	port      serial.Port
+	portName  string
+	baudRate  int
+	frameBits int  // bits on the wire per byte, including start, parity and stop bits
+	delimiter byte // byte that ends a frame; '\n' for text lines
+	keepCR    bool // keep the '\r' before a '\n' delimiter in the line
+	running   bool
+	stopCh    chan struct{}
+	doneCh    chan struct{} // signals when the reader goroutine has exited
+
+	readMu  sync.Mutex // held by the reader around each Read; taken to pause it
+	writeMu sync.Mutex // serializes writes so concurrent senders don't interleave
+
+	tapMu sync.Mutex
+	taps  map[chan []byte]struct{} // raw byte subscribers fed by the reader
+
+	policy   string               // backpressure policy for the next reader
+	active   string               // policy of the current or last reader
+	bp       backpressureCounters // for the current or last reader
+	lineCh   chan SerialLine      // the current or last reader's output
+	osBase   ErrorCounters        // driver error counts when reading started
+	osBaseOK bool
+}
+
+// Framing is the character format on the wire.
+type Framing struct {
+	DataBits int    `json:"dataBits"` // 5 to 8
+	Parity   string `json:"parity"`   // one of parityNames
+	StopBits string `json:"stopBits"` // one of stopBitsNames
+}
+
+var (
+	dataBitsNames = []string{"5", "6", "7", "8"}
+	parityNames   = []string{"None", "Odd", "Even", "Mark", "Space"}
+	stopBitsNames = []string{"1", "1.5", "2"}
+)
+
+// defaultFraming is 8N1, which almost every Arduino sketch uses.
+var defaultFraming = Framing{DataBits: 8, Parity: "None", StopBits: "1"}
+
+// mode converts the framing to go.bug.st/serial settings.
+func (f Framing) mode(baudRate int) (*serial.Mode, error) {
+	mode := &serial.Mode{BaudRate: baudRate, DataBits: f.DataBits}
+	if f.DataBits < 5 || f.DataBits > 8 {
+		return nil, fmt.Errorf("invalid data bits: %d", f.DataBits)
+	}
+	switch f.Parity {
+	case "None", "":
+		mode.Parity = serial.NoParity
+	case "Odd":
+		mode.Parity = serial.OddParity
+	case "Even":
+		mode.Parity = serial.EvenParity
+	case "Mark":
+		mode.Parity = serial.MarkParity
+	case "Space":
+		mode.Parity = serial.SpaceParity
+	default:
+		return nil, fmt.Errorf("invalid parity: %s", f.Parity)
+	}
+	switch f.StopBits {
+	case "1", "":
+		mode.StopBits = serial.OneStopBit
+	case "1.5":
+		mode.StopBits = serial.OnePointFiveStopBits
+	case "2":
+		mode.StopBits = serial.TwoStopBits
+	default:
+		return nil, fmt.Errorf("invalid stop bits: %s", f.StopBits)
+	}
+	return mode, nil
+}
+
+// bits returns the number of bits each byte takes on the wire, rounding 1.5
+// stop bits up.
+func (f Framing) bits() int {
+	n := 1 + f.DataBits + 1 // start, data, stop
+	if f.Parity != "None" && f.Parity != "" {
+		n++
+	}
+	if f.StopBits != "1" && f.StopBits != "" {
+		n++
+	}
+	return n
+}
+
+// String formats the framing in the usual short form, e.g. "8N1".
+func (f Framing) String() string {
+	parity := "N"
+	if f.Parity != "" {
+		parity = f.Parity[:1]
+	}
+	stop := f.StopBits
+	if stop == "" {
+		stop = "1"
+	}
+	return fmt.Sprintf("%d%s%s", f.DataBits, parity, stop)
 }
 
 // SerialLine represents a single line received from the serial port.
+// Timestamp is when the line was complete. For lines read from the port it is
+// the same as LastByte, and FirstByte and LastByte are estimated from the read
+// time of each chunk, the byte's position in it and the baud rate.
 type SerialLine struct {
 	Timestamp time.Time
 	Data      string
+	FirstByte time.Time // arrival of the line's first byte; zero if unknown
+	LastByte  time.Time // arrival of the delimiter
+	Seq       uint64    // position in the output, assigned by the UI; keys bookmarks
 }
 
 func NewSerialManager() *SerialManager {
 	return &SerialManager{
-		baudRate: 9600,
+		baudRate:  9600,
+		frameBits: 10,
+		delimiter: '\n',
+		policy:    BackpressureBlock,
 	}
 }
 
@@ -57,8 +166,8 @@ func (sm *SerialManager) stopReader() {
 	sm.mu.Lock()
 }
 
-// Connect opens the serial port with the configured settings.
-func (sm *SerialManager) Connect(portName string, baudRate int) error {
+// Connect opens the serial port with the given baud rate and framing.
+func (sm *SerialManager) Connect(portName string, baudRate int, framing Framing) error {
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
 
@@ -70,11 +179,9 @@ func (sm *SerialManager) Connect(portName string, baudRate int) error {
 		sm.port = nil
 	}
 
-	mode := &serial.Mode{
-		BaudRate: baudRate,
-		DataBits: 8,
-		Parity:   serial.NoParity,
-		StopBits: serial.OneStopBit,
+	mode, err := framing.mode(baudRate)
+	if err != nil {
+		return err
 	}
 
 	p, err := serial.Open(portName, mode)
@@ -86,6 +193,7 @@ func (sm *SerialManager) Connect(portName string, baudRate int) error {
 	sm.port = p
 	sm.portName = portName
 	sm.baudRate = baudRate
+	sm.frameBits = framing.bits()
 	return nil
 }
 
@@ -102,6 +210,119 @@ func (sm *SerialManager) Disconnect() {
 	}
 }
 
+// BaudRate returns the baud rate of the current or last connection.
+func (sm *SerialManager) BaudRate() int {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	return sm.baudRate
+}
+
+// PortName returns the name of the current or last opened port.
+func (sm *SerialManager) PortName() string {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	return sm.portName
+}
+
+// FrameBits returns the bits each byte takes on the wire at the current
+// settings, including start, parity and stop bits.
+func (sm *SerialManager) FrameBits() int {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	return sm.frameBits
+}
+
+// byteTime returns how long one byte takes on the wire at the current settings.
+func (sm *SerialManager) byteTime() time.Duration {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	if sm.baudRate <= 0 {
+		return 0
+	}
+	return time.Duration(sm.frameBits) * time.Second / time.Duration(sm.baudRate)
+}
+
+// chunkClock estimates when each byte of a chunk arrived. The read returns
+// just after the last byte, so earlier bytes are placed one byte time apart
+// before it, but never before the previous read returned.
+type chunkClock struct {
+	arrival  time.Time
+	prev     time.Time // arrival of the previous chunk, or when reading started
+	n        int
+	byteTime time.Duration
+}
+
+func (c chunkClock) at(i int) time.Time {
+	t := c.arrival.Add(-time.Duration(c.n-1-i) * c.byteTime)
+	if t.Before(c.prev) {
+		return c.prev
+	}
+	return t
+}
+
+// SetDelimiter sets the byte that terminates each frame. It takes effect on the
+// next chunk read, so it can be changed while connected.
+func (sm *SerialManager) SetDelimiter(delim byte) {
+	sm.mu.Lock()
+	sm.delimiter = delim
+	sm.mu.Unlock()
+}
+
+func (sm *SerialManager) frameDelimiter() byte {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	return sm.delimiter
+}
+
+// SetKeepCR chooses whether text lines keep the '\r' of a "\r\n" ending for
+// exact capture, instead of having it stripped. Like SetDelimiter, it takes
+// effect on the next chunk read.
+func (sm *SerialManager) SetKeepCR(keep bool) {
+	sm.mu.Lock()
+	sm.keepCR = keep
+	sm.mu.Unlock()
+}
+
+func (sm *SerialManager) keepCarriageReturn() bool {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	return sm.keepCR
+}
+
+// SetBackpressure sets what the reader does when the consumer falls behind.
+// It takes effect on the next StartReading.
+func (sm *SerialManager) SetBackpressure(policy string) {
+	sm.mu.Lock()
+	sm.policy = policy
+	sm.mu.Unlock()
+}
+
+// Health reports backpressure and driver error counts for the current or
+// last reader.
+func (sm *SerialManager) Health() LinkHealth {
+	sm.mu.Lock()
+	h := LinkHealth{Policy: sm.active, Backlog: len(sm.lineCh)}
+	portName, connected, base, baseOK := sm.portName, sm.port != nil, sm.osBase, sm.osBaseOK
+	sm.mu.Unlock()
+
+	h.ChannelFull = sm.bp.channelFull.Load()
+	h.Stalled = time.Duration(sm.bp.stalled.Load())
+	h.Dropped = sm.bp.dropped.Load()
+	h.Spilled = sm.bp.spilled.Load()
+	h.Spooled = int(sm.bp.spooled.Load())
+	switch {
+	case !connected:
+		h.OSErr = fmt.Errorf("not connected")
+	case !baseOK:
+		_, h.OSErr = readErrorCounters(portName)
+	default:
+		var now ErrorCounters
+		now, h.OSErr = readErrorCounters(portName)
+		h.OS = now.sub(base)
+	}
+	return h
+}
+
 // IsConnected returns true if a port is currently open.
 func (sm *SerialManager) IsConnected() bool {
 	sm.mu.Lock()
@@ -109,6 +330,96 @@ func (sm *SerialManager) IsConnected() bool {
 	return sm.port != nil
 }
 
+// Write sends data to the open serial port.
+func (sm *SerialManager) Write(data []byte) (int, error) {
+	sm.mu.Lock()
+	port := sm.port
+	sm.mu.Unlock()
+	if port == nil {
+		return 0, fmt.Errorf("port not connected")
+	}
+
+	sm.writeMu.Lock()
+	defer sm.writeMu.Unlock()
+	return port.Write(data)
+}
+
+// Exclusive pauses the line reader and gives fn direct access to the port for
+// protocols that need to own both directions, such as file transfers. Other
+// writers are blocked until fn returns, after which line reading resumes.
+func (sm *SerialManager) Exclusive(fn func(port io.ReadWriter) error) error {
+	sm.mu.Lock()
+	port := sm.port
+	sm.mu.Unlock()
+	if port == nil {
+		return fmt.Errorf("port not connected")
+	}
+
+	sm.readMu.Lock()
+	defer sm.readMu.Unlock()
+	sm.writeMu.Lock()
+	defer sm.writeMu.Unlock()
+	return fn(port)
+}
+
+// readFull fills buf from r, which is expected to return (0, nil) when its read
+// timeout expires, giving up after timeout or when cancel is closed.
+func readFull(r io.Reader, buf []byte, timeout time.Duration, cancel <-chan struct{}) error {
+	deadline := time.Now().Add(timeout)
+	got := 0
+	for got < len(buf) {
+		select {
+		case <-cancel:
+			return errTransferCancelled
+		default:
+		}
+		n, err := r.Read(buf[got:])
+		if err != nil {
+			return err
+		}
+		got += n
+		if n == 0 && time.Now().After(deadline) {
+			return errReadTimeout
+		}
+	}
+	return nil
+}
+
+// Tap subscribes to the raw bytes read from the port, before line splitting.
+// Chunks are dropped if the subscriber falls behind. Call the returned function
+// to unsubscribe.
+func (sm *SerialManager) Tap() (<-chan []byte, func()) {
+	ch := make(chan []byte, 64)
+	sm.tapMu.Lock()
+	if sm.taps == nil {
+		sm.taps = make(map[chan []byte]struct{})
+	}
+	sm.taps[ch] = struct{}{}
+	sm.tapMu.Unlock()
+
+	return ch, func() {
+		sm.tapMu.Lock()
+		delete(sm.taps, ch)
+		sm.tapMu.Unlock()
+	}
+}
+
+// publishRaw hands a copy of a freshly read chunk to every tap subscriber.
+func (sm *SerialManager) publishRaw(data []byte) {
+	sm.tapMu.Lock()
+	defer sm.tapMu.Unlock()
+	if len(sm.taps) == 0 {
+		return
+	}
+	chunk := append([]byte(nil), data...)
+	for ch := range sm.taps {
+		select {
+		case ch <- chunk:
+		default:
+		}
+	}
+}
+
 // StartReading begins reading lines from the serial port in a goroutine.
 // Each complete line is sent to the returned channel. If an error occurs,
 // a SerialLine with empty Data and non-zero Timestamp is NOT sent; instead
@@ -128,14 +439,35 @@ func (sm *SerialManager) StartReading() (<-chan SerialLine, <-chan error) {
 	sm.doneCh = make(chan struct{})
 	sm.running = true
 	port := sm.port
+	sm.lineCh = ch
+	sm.active = sm.policy
+	sm.bp.reset()
+	base, err := readErrorCounters(sm.portName)
+	sm.osBase, sm.osBaseOK = base, err == nil
+	sender := &lineSender{ch: ch, stop: sm.stopCh, policy: sm.policy, counters: &sm.bp}
+	if sm.policy == BackpressureSpill {
+		if sender.spool, err = newLineSpool(ch, sm.stopCh, &sm.bp); err != nil {
+			// Without a spill file, blocking loses nothing the app could keep.
+			log.Printf("%v; blocking instead", err)
+			sm.active = BackpressureBlock
+		}
+	}
 	sm.mu.Unlock()
+	// Bytes read first may have waited in the driver since the port opened;
+	// they can't have arrived before reading started.
+	readStart := time.Now()
 
 	go func() {
 		defer close(ch)
 		defer close(sm.doneCh)
+		if sender.spool != nil {
+			defer sender.spool.close()
+		}
 
 		buf := make([]byte, 1024)
 		var partial []byte
+		var partialFirst time.Time // arrival of partial[0]
+		lastArrival := readStart
 
 		for {
 			select {
@@ -144,29 +476,58 @@ func (sm *SerialManager) StartReading() (<-chan SerialLine, <-chan error) {
 			default:
 			}
 
+			sm.readMu.Lock()
 			n, err := port.Read(buf)
+			sm.readMu.Unlock()
 			if n > 0 {
+				clock := chunkClock{arrival: time.Now(), prev: lastArrival, n: n, byteTime: sm.byteTime()}
+				lastArrival = clock.arrival
+				sm.publishRaw(buf[:n])
+				// base is where this chunk starts in partial; earlier bytes
+				// share partialFirst as their best known time.
+				base := len(partial)
+				if base == 0 {
+					partialFirst = clock.at(0)
+				}
 				partial = append(partial, buf[:n]...)
+				byteAt := func(i int) time.Time {
+					if i < base {
+						return partialFirst
+					}
+					return clock.at(i - base)
+				}
+				delim := sm.frameDelimiter()
+				keepCR := sm.keepCarriageReturn()
 				// Extract complete lines
 				for {
-					idx := bytes.IndexByte(partial, '\n')
+					idx := bytes.IndexByte(partial, delim)
 					if idx < 0 {
 						break
 					}
 					lineData := string(partial[:idx])
-					// Strip trailing \r if present
-					if len(lineData) > 0 && lineData[len(lineData)-1] == '\r' {
-						lineData = lineData[:len(lineData)-1]
+					first, last := partialFirst, byteAt(idx)
+					if idx+1 < len(partial) {
+						partialFirst = byteAt(idx + 1)
 					}
 					partial = partial[idx+1:]
+					base -= idx + 1
+					if delim == '\n' {
+						// Strip trailing \r if present
+						if !keepCR && len(lineData) > 0 && lineData[len(lineData)-1] == '\r' {
+							lineData = lineData[:len(lineData)-1]
+						}
+					} else if lineData == "" {
+						// Binary framings often send back-to-back delimiters
+						continue
+					}
 
 					line := SerialLine{
-						Timestamp: time.Now(),
+						Timestamp: last,
 						Data:      lineData,
+						FirstByte: first,
+						LastByte:  last,
 					}
-					select {
-					case ch <- line:
-					case <-sm.stopCh:
+					if !sender.send(line) {
 						return
 					}
 				}
@@ -0,0 +1,69 @@
+//go:build linux
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// tiocgicount is TIOCGICOUNT from asm-generic/ioctls.h, which x/sys/unix
+// doesn't define.
+const tiocgicount = 0x545D
+
+// serialICounter mirrors struct serial_icounter_struct from linux/serial.h.
+type serialICounter struct {
+	cts, dsr, rng, dcd, rx, tx int32
+	frame, overrun, parity     int32
+	brk, bufOverrun            int32
+	reserved                   [9]int32
+}
+
+// readErrorCounters reads the driver's receive error counts for an open port.
+// The serial package opens ports exclusively and keeps its descriptor to
+// itself, so this finds the process's descriptor for the device. Drivers
+// without counts, such as USB CDC-ACM adapters and ptys, report an error.
+func readErrorCounters(portName string) (ErrorCounters, error) {
+	fd, err := openFD(portName)
+	if err != nil {
+		return ErrorCounters{}, err
+	}
+	var c serialICounter
+	if _, _, errno := unix.Syscall(unix.SYS_IOCTL, uintptr(fd), tiocgicount, uintptr(unsafe.Pointer(&c))); errno != 0 {
+		return ErrorCounters{}, fmt.Errorf("driver doesn't report error counts: %w", errno)
+	}
+	return ErrorCounters{
+		Overrun:       uint64(uint32(c.overrun)),
+		BufferOverrun: uint64(uint32(c.bufOverrun)),
+		Frame:         uint64(uint32(c.frame)),
+		Parity:        uint64(uint32(c.parity)),
+		Break:         uint64(uint32(c.brk)),
+	}, nil
+}
+
+// openFD returns a descriptor this process holds open on the device at path.
+func openFD(path string) (int, error) {
+	target, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to resolve port: %w", err)
+	}
+	entries, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		return 0, fmt.Errorf("failed to list open files: %w", err)
+	}
+	for _, e := range entries {
+		link, err := os.Readlink(filepath.Join("/proc/self/fd", e.Name()))
+		if err != nil || link != target {
+			continue
+		}
+		if fd, err := strconv.Atoi(e.Name()); err == nil {
+			return fd, nil
+		}
+	}
+	return 0, fmt.Errorf("port %s is not open", path)
+}
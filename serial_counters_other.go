@@ -0,0 +1,11 @@
+//go:build !linux
+
+package main
+
+import "fmt"
+
+// readErrorCounters reports that driver error counts aren't read on this
+// platform.
+func readErrorCounters(portName string) (ErrorCounters, error) {
+	return ErrorCounters{}, fmt.Errorf("driver error counts are only read on Linux")
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestChunkClockFirstChunkAfterConnect(t *testing.T) {
+	// A full buffer read right after connecting at 9600 baud would reach
+	// back about a second, to before the port was opened.
+	connect := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	clock := chunkClock{arrival: connect.Add(10 * time.Millisecond), prev: connect, n: 1024, byteTime: time.Second / 960}
+	if got := clock.at(0); !got.Equal(connect) {
+		t.Errorf("first byte at %s, want the connect time %s", got, connect)
+	}
+	for i := 1; i < clock.n; i++ {
+		if clock.at(i).Before(clock.at(i - 1)) {
+			t.Fatalf("byte %d arrived before byte %d", i, i-1)
+		}
+	}
+	if got := clock.at(clock.n - 1); !got.Equal(clock.arrival) {
+		t.Errorf("last byte at %s, want the read's return %s", got, clock.arrival)
+	}
+}
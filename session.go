@@ -0,0 +1,112 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+	"unicode/utf8"
+)
+
+// sessionVersion is the version of the session file format.
+const sessionVersion = 1
+
+// Session is a saved capture: the received lines with their bookmarks and
+// statistics, so a capture can be reopened and annotated later.
+type Session struct {
+	Lines     []SerialLine      // oldest first, with their Seqs
+	Bookmarks map[uint64]string // notes by line Seq; "" for a bookmark without a note
+	Connects  []time.Time       // connection start times, oldest first
+	Stats     *StatsSnapshot    // line statistics when saved; nil in files without them
+}
+
+// sessionFile is the JSON layout of a session file.
+type sessionFile struct {
+	Version   int               `json:"version"`
+	Saved     time.Time         `json:"saved"`
+	Connects  []time.Time       `json:"connects,omitempty"`
+	Lines     []sessionLine     `json:"lines"`
+	Bookmarks map[uint64]string `json:"bookmarks,omitempty"`
+	Stats     *StatsSnapshot    `json:"stats,omitempty"`
+}
+
+// sessionLine is one saved line. Text holds valid UTF-8 lines; others are
+// kept byte for byte in Raw.
+type sessionLine struct {
+	Seq       uint64    `json:"seq"`
+	Timestamp time.Time `json:"timestamp"`
+	FirstByte time.Time `json:"firstByte,omitzero"`
+	LastByte  time.Time `json:"lastByte,omitzero"`
+	Text      string    `json:"text,omitempty"`
+	Raw       []byte    `json:"raw,omitempty"`
+}
+
+// SaveSession writes a session file.
+func SaveSession(path string, s Session) error {
+	f := sessionFile{
+		Version:   sessionVersion,
+		Saved:     time.Now(),
+		Connects:  s.Connects,
+		Lines:     make([]sessionLine, len(s.Lines)),
+		Bookmarks: s.Bookmarks,
+		Stats:     s.Stats,
+	}
+	for i, line := range s.Lines {
+		l := sessionLine{Seq: line.Seq, Timestamp: line.Timestamp, FirstByte: line.FirstByte, LastByte: line.LastByte}
+		if utf8.ValidString(line.Data) {
+			l.Text = line.Data
+		} else {
+			l.Raw = []byte(line.Data)
+		}
+		f.Lines[i] = l
+	}
+
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal session: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write session: %w", err)
+	}
+	return nil
+}
+
+// LoadSession reads a session file. Lines are returned in Seq order, and
+// bookmarks on lines the file doesn't contain are dropped.
+func LoadSession(path string) (Session, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Session{}, fmt.Errorf("failed to read session: %w", err)
+	}
+	var f sessionFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return Session{}, fmt.Errorf("failed to parse session: %w", err)
+	}
+	if f.Version > sessionVersion {
+		return Session{}, fmt.Errorf("session was written by a newer version (%d)", f.Version)
+	}
+
+	s := Session{
+		Lines:     make([]SerialLine, len(f.Lines)),
+		Bookmarks: make(map[uint64]string),
+		Connects:  f.Connects,
+		Stats:     f.Stats,
+	}
+	for i, l := range f.Lines {
+		data := l.Text
+		if l.Raw != nil {
+			data = string(l.Raw)
+		}
+		s.Lines[i] = SerialLine{Timestamp: l.Timestamp, Data: data, FirstByte: l.FirstByte, LastByte: l.LastByte, Seq: l.Seq}
+	}
+	slices.SortStableFunc(s.Lines, func(a, b SerialLine) int { return cmp.Compare(a.Seq, b.Seq) })
+	slices.SortFunc(s.Connects, func(a, b time.Time) int { return a.Compare(b) })
+	for seq, note := range f.Bookmarks {
+		if lineIndex(s.Lines, seq) >= 0 {
+			s.Bookmarks[seq] = note
+		}
+	}
+	return s, nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestSessionRoundTrip(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.Local)
+	want := Session{
+		Lines: []SerialLine{
+			{Seq: 7, Timestamp: now, FirstByte: now.Add(-time.Millisecond), LastByte: now, Data: "temp=21.5"},
+			{Seq: 8, Timestamp: now.Add(time.Second), Data: "\xff\x00binary"},
+		},
+		Bookmarks: map[uint64]string{7: "", 8: "garbage after reset"},
+		Connects:  []time.Time{now.Add(-time.Minute)},
+		Stats:     &StatsSnapshot{Since: now.Add(-time.Minute), Lines: 2, Bytes: 20, Longest: 9, MaxGap: time.Second, InvalidUTF8: 1, Drops: 3},
+	}
+	path := filepath.Join(t.TempDir(), "session.json")
+	if err := SaveSession(path, want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadSession(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got.Lines) != len(want.Lines) {
+		t.Fatalf("loaded %d lines, want %d", len(got.Lines), len(want.Lines))
+	}
+	for i, line := range got.Lines {
+		w := want.Lines[i]
+		if line.Seq != w.Seq || line.Data != w.Data || !line.Timestamp.Equal(w.Timestamp) ||
+			!line.FirstByte.Equal(w.FirstByte) || !line.LastByte.Equal(w.LastByte) {
+			t.Errorf("line %d: %+v, want %+v", i, line, w)
+		}
+	}
+	if !maps.Equal(got.Bookmarks, want.Bookmarks) {
+		t.Errorf("bookmarks %v, want %v", got.Bookmarks, want.Bookmarks)
+	}
+	if got.Stats == nil || got.Stats.Lines != 2 || got.Stats.Bytes != 20 || got.Stats.MaxGap != time.Second ||
+		got.Stats.InvalidUTF8 != 1 || got.Stats.Drops != 3 || !got.Stats.Since.Equal(want.Stats.Since) {
+		t.Errorf("stats %+v, want %+v", got.Stats, want.Stats)
+	}
+	if !slices.EqualFunc(got.Connects, want.Connects, time.Time.Equal) {
+		t.Errorf("connects %v, want %v", got.Connects, want.Connects)
+	}
+}
+
+func TestLoadSessionDropsOrphanBookmarks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.json")
+	data := `{"version":1,"lines":[{"seq":3,"timestamp":"2026-01-02T03:04:05Z","text":"x"}],"bookmarks":{"2":"gone","3":"kept"}}`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := LoadSession(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[uint64]string{3: "kept"}; !maps.Equal(s.Bookmarks, want) {
+		t.Errorf("bookmarks %v, want %v", s.Bookmarks, want)
+	}
+}
+
+func TestLoadSessionRefusesNewerVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.json")
+	if err := os.WriteFile(path, []byte(`{"version":99,"lines":[]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadSession(path); err == nil {
+		t.Fatal("loaded a session from a newer version")
+	}
+}
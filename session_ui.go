@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+)
+
+// showSaveSessionDialog saves the buffered lines, their bookmarks and the
+// line statistics.
+func (ui *AppUI) showSaveSessionDialog() {
+	ui.mu.Lock()
+	s := Session{
+		Lines:     slices.Clone(ui.lines),
+		Bookmarks: maps.Clone(ui.bookmarks),
+		Connects:  slices.Clone(ui.connects),
+	}
+	ui.mu.Unlock()
+	stats := ui.stats.Snapshot()
+	s.Stats = &stats
+	if len(s.Lines) == 0 {
+		dialog.ShowInformation("Save Session", "There are no lines to save.", ui.window)
+		return
+	}
+
+	fd := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil || writer == nil {
+			return
+		}
+		writer.Close()
+		if err := SaveSession(localPath(writer.URI()), s); err != nil {
+			dialog.ShowError(err, ui.window)
+		}
+	}, ui.window)
+	fd.SetFileName("session.json")
+	fd.SetFilter(storage.NewExtensionFileFilter([]string{".json"}))
+	fd.Show()
+}
+
+// showOpenSessionDialog replaces the buffered lines, bookmarks and
+// statistics with a saved session's.
+func (ui *AppUI) showOpenSessionDialog() {
+	if ui.connected.Load() {
+		dialog.ShowInformation("Open Session", "Disconnect before opening a session.", ui.window)
+		return
+	}
+	fd := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil || reader == nil {
+			return
+		}
+		reader.Close()
+		s, err := LoadSession(localPath(reader.URI()))
+		if err != nil {
+			dialog.ShowError(err, ui.window)
+			return
+		}
+		ui.loadSession(s)
+		if len(s.Lines) > maxLines {
+			dialog.ShowInformation("Open Session", fmt.Sprintf("Only the last %d of %d lines were loaded.", maxLines, len(s.Lines)), ui.window)
+		}
+	}, ui.window)
+	fd.SetFilter(storage.NewExtensionFileFilter([]string{".json"}))
+	fd.Show()
+}
+
+// loadSession shows a session's lines in place of the buffer. New lines
+// continue its Seq numbering.
+func (ui *AppUI) loadSession(s Session) {
+	lines := s.Lines
+	if len(lines) > maxLines {
+		lines = lines[len(lines)-maxLines:]
+	}
+
+	ui.mu.Lock()
+	ui.lines = lines
+	ui.nextSeq = 0
+	if len(lines) > 0 {
+		ui.nextSeq = lines[len(lines)-1].Seq
+	}
+	ui.bookmarks = s.Bookmarks
+	if ui.bookmarks == nil {
+		ui.bookmarks = make(map[uint64]string)
+	}
+	ui.pruneBookmarksLocked()
+	ui.connects = s.Connects
+	ui.connectedAt = time.Time{}
+	if len(s.Connects) > 0 {
+		ui.connectedAt = s.Connects[len(s.Connects)-1]
+	}
+	ui.selAnchor, ui.selStart, ui.selEnd, ui.selCursor = 0, 0, 0, 0
+	ui.frozenLines = nil
+	if ui.paused {
+		ui.frozenLines = ui.lines
+	}
+	ui.pausedCount = 0
+	paused := ui.paused
+	ui.rebuildDisplayLines()
+	ui.tableView.rebuildLocked()
+	ui.mu.Unlock()
+	if s.Stats != nil {
+		ui.stats.Restore(*s.Stats)
+	}
+
+	if paused {
+		ui.updatePausedLabel()
+	}
+	ui.output.UnselectAll()
+	ui.refreshOutput()
+}
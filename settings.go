@@ -0,0 +1,224 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const settingsFileName = "settings.json"
+
+// settingsVersion is bumped whenever the layout of Settings changes in a way
+// that needs migrating; see migrateSettings.
+const settingsVersion = 2
+
+// Settings is everything the app remembers between runs.
+type Settings struct {
+	Version    int                `json:"version"`
+	Window     WindowSettings     `json:"window"`
+	Connection ConnectionSettings `json:"connection"`
+	Display    DisplaySettings    `json:"display"`
+	Export     ExportSettings     `json:"export"`
+	Templates  []HeaderTemplate   `json:"templates"`
+	Modbus     []ModbusPoll       `json:"modbusPolls,omitempty"`
+	Profiles   []Profile          `json:"profiles,omitempty"`
+	Profile    string             `json:"profile,omitempty"` // last applied profile
+	API        APISettings        `json:"api"`
+	Bridge     BridgeSettings     `json:"bridge"`
+	MQTT       MQTTSettings       `json:"mqtt"`
+	Sinks      []SinkConfig       `json:"sinks,omitempty"`
+	Simulator  SimulatorSettings  `json:"simulator"`
+}
+
+// WindowSettings is the main window's size and placement. Position is only
+// read and restored on Windows: Fyne can't place windows, so elsewhere it's
+// nil and the window manager decides.
+type WindowSettings struct {
+	Width      float32         `json:"width"`
+	Height     float32         `json:"height"`
+	FullScreen bool            `json:"fullScreen,omitempty"`
+	Position   *WindowPosition `json:"position,omitempty"` // nil off Windows
+}
+
+// WindowPosition is where the window sits on the desktop when not maximized.
+type WindowPosition struct {
+	X         int  `json:"x"`
+	Y         int  `json:"y"`
+	Maximized bool `json:"maximized"`
+}
+
+// ConnectionSettings are the last used connection parameters.
+type ConnectionSettings struct {
+	Port         string  `json:"port"`
+	BaudRate     int     `json:"baudRate"`
+	Framing      Framing `json:"framing"`
+	LineEnding   string  `json:"lineEnding"`
+	Backpressure string  `json:"backpressure"` // one of backpressurePolicies
+	Encoding     string  `json:"encoding"`     // one of encodingNames
+	KeepCR       bool    `json:"keepCR"`       // keep '\r' in received lines
+}
+
+// DisplaySettings are the output view toggles.
+type DisplaySettings struct {
+	Autoscroll    bool   `json:"autoscroll"`
+	Timestamps    bool   `json:"timestamps"`
+	TimestampMode string `json:"timestampMode"`
+	Decoder       string `json:"decoder"`
+	TableMode     bool   `json:"tableMode"`
+	Invisibles    string `json:"invisibles"` // one of invisiblesModes
+}
+
+// ExportSettings are the export dialog's last choices.
+type ExportSettings struct {
+	Format             string `json:"format"`
+	IncludeTimestamps  bool   `json:"includeTimestamps"`
+	IncludeFirstByte   bool   `json:"includeFirstByte"`
+	IncludeBookmarks   bool   `json:"includeBookmarks"`
+	TimestampFormat    string `json:"timestampFormat"`
+	InputDelimiter     string `json:"inputDelimiter"`
+	TrimFields         bool   `json:"trimFields"`
+	Mismatch           string `json:"mismatch"`
+	InferTypes         bool   `json:"inferTypes"`
+	HeaderSource       string `json:"headerSource"`
+	CSVDelimiter       string `json:"csvDelimiter"`
+	JSONLIncludeRaw    bool   `json:"jsonlIncludeRaw"`
+	XLSXSheetName      string `json:"xlsxSheetName"`
+	XLSXFreezeHeader   bool   `json:"xlsxFreezeHeader"`
+	ParquetCompression string `json:"parquetCompression"`
+}
+
+// APISettings configure the local HTTP API.
+type APISettings struct {
+	Enabled bool   `json:"enabled"`
+	Port    int    `json:"port"`
+	Token   string `json:"token"`
+}
+
+// BridgeSettings configure the serial-to-TCP bridge.
+type BridgeSettings struct {
+	Port          int    `json:"port"`
+	AllInterfaces bool   `json:"allInterfaces"`
+	WritePolicy   string `json:"writePolicy"`
+}
+
+// defaultSettings returns the settings used on first run.
+func defaultSettings() Settings {
+	return Settings{
+		Version:    settingsVersion,
+		Window:     WindowSettings{Width: 800, Height: 500},
+		Connection: ConnectionSettings{BaudRate: 9600, Framing: defaultFraming, LineEnding: "LF", Backpressure: BackpressureBlock, Encoding: EncodingUTF8},
+		Display: DisplaySettings{
+			Autoscroll:    true,
+			TimestampMode: DisplayWallClock,
+			Decoder:       csvDecoder{}.Name(),
+			Invisibles:    InvisiblesHidden,
+		},
+		Export: ExportSettings{
+			Format:             FormatCSV,
+			TimestampFormat:    TimestampLocal,
+			InputDelimiter:     "Comma",
+			TrimFields:         true,
+			Mismatch:           MismatchPad,
+			HeaderSource:       "None",
+			CSVDelimiter:       "Comma",
+			XLSXSheetName:      "Serial Data",
+			XLSXFreezeHeader:   true,
+			ParquetCompression: parquetCompressionNames[0],
+		},
+		Templates: []HeaderTemplate{},
+		API:       APISettings{Port: defaultAPIPort},
+		Bridge:    BridgeSettings{Port: defaultBridgePort, WritePolicy: BridgeWriteShared},
+		MQTT:      MQTTSettings{Broker: "tcp://localhost:1883", ClientID: "arduino-serial-monitor", TopicTemplate: defaultMQTTTopic},
+		Simulator: defaultSimulatorSettings,
+	}
+}
+
+// LoadSettings reads settings from disk. On first run it starts from the
+// defaults and migrates templates saved by older versions in templates.json.
+func LoadSettings() (Settings, error) {
+	dir, err := configDir()
+	if err != nil {
+		return defaultSettings(), err
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, settingsFileName))
+	if os.IsNotExist(err) {
+		s := defaultSettings()
+		templates, err := LoadTemplates()
+		if err != nil {
+			return s, err
+		}
+		if len(templates) > 0 {
+			for _, header := range templates {
+				s.Templates = append(s.Templates, templateFromHeader(header, header))
+			}
+			if err := SaveSettings(s); err != nil {
+				return s, err
+			}
+			os.Rename(filepath.Join(dir, templatesFileName), filepath.Join(dir, templatesFileName+".bak"))
+		}
+		return s, nil
+	}
+	if err != nil {
+		return defaultSettings(), fmt.Errorf("failed to read settings: %w", err)
+	}
+
+	// Start from the defaults so fields added since the file was written
+	// get sensible values.
+	s := defaultSettings()
+	if err := json.Unmarshal(data, &s); err != nil {
+		return defaultSettings(), fmt.Errorf("failed to parse settings: %w", err)
+	}
+	if s.Version > settingsVersion {
+		return s, fmt.Errorf("settings were written by a newer version (%d)", s.Version)
+	}
+	migrateSettings(&s)
+	return s, nil
+}
+
+// migrateSettings upgrades settings written by older versions in place.
+// Version 1 stored templates as plain header strings; HeaderTemplate's
+// UnmarshalJSON converts those as they are read.
+func migrateSettings(s *Settings) {
+	if s.Templates == nil {
+		s.Templates = []HeaderTemplate{}
+	}
+	s.Version = settingsVersion
+}
+
+// SaveSettings writes settings to disk.
+func SaveSettings(s Settings) error {
+	dir, err := configDir()
+	if err != nil {
+		return err
+	}
+
+	// Never replace settings written by a newer version, which may hold
+	// things this one doesn't know about.
+	path := filepath.Join(dir, settingsFileName)
+	if data, err := os.ReadFile(path); err == nil {
+		var existing struct {
+			Version int `json:"version"`
+		}
+		if json.Unmarshal(data, &existing) == nil && existing.Version > settingsVersion {
+			return fmt.Errorf("settings were written by a newer version (%d); not overwriting them", existing.Version)
+		}
+	}
+
+	s.Version = settingsVersion
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal settings: %w", err)
+	}
+
+	// Write to a temporary file first so a crash can't leave a truncated file.
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write settings: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write settings: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// useConfigDir points configDir at a fresh temporary directory.
+func useConfigDir(t *testing.T) string {
+	t.Helper()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("APPDATA", os.Getenv("XDG_CONFIG_HOME"))
+	dir, err := configDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestSaveSettingsKeepsNewerFile(t *testing.T) {
+	dir := useConfigDir(t)
+	path := filepath.Join(dir, settingsFileName)
+	newer := []byte(`{"version": 99, "templates": [{"name": "future"}]}`)
+	if err := os.WriteFile(path, newer, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := LoadSettings(); err == nil {
+		t.Error("loading settings from a newer version succeeded")
+	}
+	if err := SaveSettings(defaultSettings()); err == nil {
+		t.Error("saving over settings from a newer version succeeded")
+	}
+	if data, _ := os.ReadFile(path); string(data) != string(newer) {
+		t.Errorf("settings file was changed to %s", data)
+	}
+}
+
+func TestLoadSettingsReportsCorruptFile(t *testing.T) {
+	dir := useConfigDir(t)
+	if err := os.WriteFile(filepath.Join(dir, settingsFileName), []byte("{not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadSettings(); err == nil {
+		t.Error("corrupt settings loaded without an error")
+	}
+}
+
+func TestSettingsRoundTripWindowPosition(t *testing.T) {
+	useConfigDir(t)
+	s := defaultSettings()
+	s.Window.Position = &WindowPosition{X: -1200, Y: 40, Maximized: true}
+	if err := SaveSettings(s); err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadSettings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Window.Position == nil || *got.Window.Position != *s.Window.Position {
+		t.Errorf("position %+v, want %+v", got.Window.Position, s.Window.Position)
+	}
+}
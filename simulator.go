@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SimulatorSettings configure the fake device behind the virtual port.
+type SimulatorSettings struct {
+	AutoStart  bool    `json:"autoStart"`  // start with the app and select the virtual port
+	IntervalMS int     `json:"intervalMs"` // time between sensor lines
+	Columns    int     `json:"columns"`    // sensor values per CSV line
+	Header     bool    `json:"header"`     // print a CSV header line first
+	Echo       bool    `json:"echo"`       // echo received commands back
+	NoiseRate  float64 `json:"noiseRate"`  // chance, 0 to 1, of a line of random bytes instead of a sensor line
+	BurstEvery int     `json:"burstEvery"` // every this many lines, send a burst; never if 0
+	BurstSize  int     `json:"burstSize"`  // lines per burst, sent back to back
+	Seed       int64   `json:"seed"`       // random seed for repeatable runs; 0 picks one at start
+	Script     string  `json:"script"`     // device script file used instead of the settings above, if set
+}
+
+var defaultSimulatorSettings = SimulatorSettings{IntervalMS: 200, Columns: 3, Header: true, Echo: true}
+
+// validate checks the settings describe a runnable device.
+func (s SimulatorSettings) validate() error {
+	if s.Script != "" {
+		return nil // checked when loaded
+	}
+	if s.IntervalMS <= 0 {
+		return fmt.Errorf("interval must be positive")
+	}
+	if s.Columns <= 0 {
+		return fmt.Errorf("columns must be positive")
+	}
+	if s.NoiseRate < 0 || s.NoiseRate > 1 {
+		return fmt.Errorf("noise rate must be between 0 and 1")
+	}
+	if s.BurstEvery < 0 || s.BurstSize < 0 {
+		return fmt.Errorf("burst settings must not be negative")
+	}
+	return nil
+}
+
+// script returns the device the settings describe: the device script file
+// if one is set, otherwise a generated one printing CSV sensor readings.
+func (s SimulatorSettings) script() (DeviceScript, error) {
+	if s.Script != "" {
+		return LoadDeviceScript(s.Script)
+	}
+	em := Emitter{IntervalMS: s.IntervalMS, BurstEvery: s.BurstEvery, BurstSize: s.BurstSize}
+	var header []string
+	if s.Header {
+		header = []string{"millis"}
+	}
+	for c := 0; c < s.Columns; c++ {
+		em.Signals = append(em.Signals, Signal{
+			Wave: WaveSine, Offset: 20, Amplitude: 10, PeriodMS: 60000,
+			Phase: float64(c) / float64(s.Columns), Noise: 0.2, Decimals: 2,
+		})
+		if s.Header {
+			header = append(header, fmt.Sprintf("sensor%d", c+1))
+		}
+	}
+	script := DeviceScript{
+		Seed:     s.Seed,
+		Echo:     s.Echo,
+		Emitters: []Emitter{em},
+		Faults:   Faults{GarbageRate: s.NoiseRate},
+	}
+	if s.Header {
+		script.Banner = []string{strings.Join(header, ",")}
+	}
+	return script, nil
+}
@@ -0,0 +1,123 @@
+//go:build linux
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/creack/pty"
+	"golang.org/x/sys/unix"
+)
+
+// simulatorSupported reports whether this platform can create virtual ports.
+const simulatorSupported = true
+
+// Simulator runs a fake device on the master side of a pty pair. The slave
+// side is an ordinary serial device path that SerialManager.Connect opens.
+type Simulator struct {
+	master *os.File
+	slave  *os.File // kept open so the master doesn't see EIO between connections
+	stop   chan struct{}
+	done   chan struct{}
+
+	unplugged bool // the script disconnected the device; read after done closes
+}
+
+// StartSimulator creates a pty pair and starts the fake device.
+func StartSimulator(cfg SimulatorSettings) (*Simulator, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	script, err := cfg.script()
+	if err != nil {
+		return nil, err
+	}
+	master, slave, err := pty.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create virtual port: %w", err)
+	}
+	if master, err = pollable(master); err != nil {
+		slave.Close()
+		return nil, fmt.Errorf("failed to create virtual port: %w", err)
+	}
+	// Until the app opens the port, the slave's line discipline would echo
+	// the device's output back to it and translate line endings.
+	if err := makeRaw(slave); err != nil {
+		master.Close()
+		slave.Close()
+		return nil, fmt.Errorf("failed to create virtual port: %w", err)
+	}
+
+	s := &Simulator{master: master, slave: slave, stop: make(chan struct{}), done: make(chan struct{})}
+	device := newEmulator(script)
+	var writeMu sync.Mutex // keeps replies from splitting emitted lines
+	go func() {
+		defer close(s.done)
+		if device.run(master, &writeMu, s.stop) {
+			// Like pulling the cable: the app's reads fail and the port
+			// goes away.
+			s.unplugged = true
+			master.Close()
+		}
+	}()
+	go device.serve(master, master, &writeMu)
+	return s, nil
+}
+
+// PortName returns the device path to connect to.
+func (s *Simulator) PortName() string {
+	return s.slave.Name()
+}
+
+// Unplugged reports whether the device script disconnected the device.
+func (s *Simulator) Unplugged() bool {
+	select {
+	case <-s.done:
+		return s.unplugged
+	default:
+		return false
+	}
+}
+
+// Close stops the fake device and removes the virtual port.
+func (s *Simulator) Close() error {
+	close(s.stop)
+	s.master.Close() // unblocks a write stuck on a full buffer
+	<-s.done
+	return s.slave.Close()
+}
+
+// pollable returns f reopened in non-blocking mode, so the runtime poller
+// serves its reads and Close interrupts a pending one. A blocking Read would
+// hold the pty open, and unplugging wouldn't reach the app. It closes f.
+func pollable(f *os.File) (*os.File, error) {
+	fd, err := unix.Dup(int(f.Fd()))
+	f.Close()
+	if err != nil {
+		return nil, err
+	}
+	if err := unix.SetNonblock(fd, true); err != nil {
+		unix.Close(fd)
+		return nil, err
+	}
+	return os.NewFile(uintptr(fd), f.Name()), nil
+}
+
+// makeRaw puts a terminal into raw mode, like cfmakeraw.
+func makeRaw(f *os.File) error {
+	fd := int(f.Fd())
+	t, err := unix.IoctlGetTermios(fd, unix.TCGETS)
+	if err != nil {
+		return err
+	}
+	t.Iflag &^= unix.IGNBRK | unix.BRKINT | unix.PARMRK | unix.ISTRIP | unix.INLCR | unix.IGNCR | unix.ICRNL | unix.IXON
+	t.Oflag &^= unix.OPOST
+	t.Lflag &^= unix.ECHO | unix.ECHONL | unix.ICANON | unix.ISIG | unix.IEXTEN
+	t.Cflag &^= unix.CSIZE | unix.PARENB
+	t.Cflag |= unix.CS8
+	t.Cc[unix.VMIN] = 1
+	t.Cc[unix.VTIME] = 0
+	return unix.IoctlSetTermios(fd, unix.TCSETS, t)
+}
@@ -0,0 +1,95 @@
+//go:build linux
+
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// nextLine returns the next line read from the port, failing after a timeout.
+func nextLine(t *testing.T, lines <-chan SerialLine, errs <-chan error) string {
+	t.Helper()
+	select {
+	case line := <-lines:
+		return line.Data
+	case err := <-errs:
+		t.Fatalf("read failed: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no line from the simulator")
+	}
+	return ""
+}
+
+// TestSimulatorThroughSerialManager opens the virtual port like a real one
+// and checks the device's output and echo arrive through the reader.
+func TestSimulatorThroughSerialManager(t *testing.T) {
+	sim, err := StartSimulator(SimulatorSettings{IntervalMS: 20, Columns: 3, Header: true, Echo: true, Seed: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sim.Close()
+
+	sm := NewSerialManager()
+	if err := sm.Connect(sim.PortName(), 115200, defaultFraming); err != nil {
+		t.Fatal(err)
+	}
+	defer sm.Disconnect()
+	lines, errs := sm.StartReading()
+
+	if got := nextLine(t, lines, errs); got != "millis,sensor1,sensor2,sensor3" {
+		t.Fatalf("first line %q, want the CSV header", got)
+	}
+	for range 5 {
+		if fields := strings.Split(nextLine(t, lines, errs), ","); len(fields) != 4 {
+			t.Fatalf("sensor line has %d fields, want 4", len(fields))
+		}
+	}
+
+	if _, err := sm.Write([]byte("hello\n")); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.After(5 * time.Second)
+	for {
+		select {
+		case <-deadline:
+			t.Fatal("command was never echoed")
+		default:
+		}
+		if nextLine(t, lines, errs) == "> hello" {
+			return
+		}
+	}
+}
+
+func TestSimulatorFirstLineNotBeforeConnect(t *testing.T) {
+	sim, err := StartSimulator(SimulatorSettings{IntervalMS: 1000, Columns: 3, Header: true, Seed: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sim.Close()
+
+	// At 300 baud the banner's bytes are 33 ms apart, so stamping the first
+	// chunk back from its arrival would put it well before the connect.
+	sm := NewSerialManager()
+	if err := sm.Connect(sim.PortName(), 300, defaultFraming); err != nil {
+		t.Fatal(err)
+	}
+	defer sm.Disconnect()
+	time.Sleep(50 * time.Millisecond) // let the banner wait in the driver
+	connectedAt := time.Now()
+	lines, errs := sm.StartReading()
+
+	select {
+	case line := <-lines:
+		if line.FirstByte.Before(connectedAt) || line.Timestamp.Before(connectedAt) {
+			t.Errorf("first line stamped %s (first byte %s), before reading started at %s",
+				line.Timestamp, line.FirstByte, connectedAt)
+		}
+	case err := <-errs:
+		t.Fatal(err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no line from the simulator")
+	}
+}
@@ -0,0 +1,32 @@
+//go:build !linux
+
+package main
+
+import "fmt"
+
+// simulatorSupported reports whether this platform can create virtual ports.
+const simulatorSupported = false
+
+// Simulator is only available on Linux, which provides pty pairs that behave
+// like serial devices.
+type Simulator struct{}
+
+// StartSimulator reports that virtual ports aren't supported here.
+func StartSimulator(cfg SimulatorSettings) (*Simulator, error) {
+	return nil, fmt.Errorf("the simulator needs Linux pseudo-terminals")
+}
+
+// PortName returns the device path to connect to.
+func (s *Simulator) PortName() string {
+	return ""
+}
+
+// Unplugged reports whether the device script disconnected the device.
+func (s *Simulator) Unplugged() bool {
+	return false
+}
+
+// Close does nothing.
+func (s *Simulator) Close() error {
+	return nil
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+)
+
+// startSimulator starts the fake device and selects its virtual port, ready
+// to connect.
+func (ui *AppUI) startSimulator(cfg SimulatorSettings) error {
+	sim, err := StartSimulator(cfg)
+	if err != nil {
+		return err
+	}
+	ui.simulator = sim
+	ui.refreshPorts()
+	ui.portSelect.SetSelected(sim.PortName())
+	return nil
+}
+
+// stopSimulator stops the fake device, disconnecting first if its port is
+// open.
+func (ui *AppUI) stopSimulator() {
+	if ui.simulator == nil {
+		return
+	}
+	if ui.connected.Load() && ui.serial.PortName() == ui.simulator.PortName() {
+		ui.serial.Disconnect()
+		ui.setDisconnectedState()
+	}
+	ui.simulator.Close()
+	ui.simulator = nil
+	ui.refreshPorts()
+}
+
+// showSimulatorDialog configures, starts and stops the fake device.
+func (ui *AppUI) showSimulatorDialog() {
+	cfg := ui.settings.Simulator
+	intervalEntry := widget.NewEntry()
+	intervalEntry.SetText(strconv.Itoa(cfg.IntervalMS))
+	columnsEntry := widget.NewEntry()
+	columnsEntry.SetText(strconv.Itoa(cfg.Columns))
+	headerCheck := widget.NewCheck("Print a CSV header first", nil)
+	headerCheck.SetChecked(cfg.Header)
+	echoCheck := widget.NewCheck("Echo received commands", nil)
+	echoCheck.SetChecked(cfg.Echo)
+	noiseEntry := widget.NewEntry()
+	noiseEntry.SetText(strconv.FormatFloat(cfg.NoiseRate*100, 'f', -1, 64))
+	burstEveryEntry := widget.NewEntry()
+	burstEveryEntry.SetPlaceHolder("0 for no bursts")
+	burstEveryEntry.SetText(strconv.Itoa(cfg.BurstEvery))
+	burstSizeEntry := widget.NewEntry()
+	burstSizeEntry.SetText(strconv.Itoa(cfg.BurstSize))
+	seedEntry := widget.NewEntry()
+	seedEntry.SetPlaceHolder("0 for a new seed each run")
+	seedEntry.SetText(strconv.FormatInt(cfg.Seed, 10))
+	scriptEntry := widget.NewEntry()
+	scriptEntry.SetPlaceHolder("built-in sensor device if empty")
+	scriptEntry.SetText(cfg.Script)
+	browseBtn := widget.NewButton("Browse...", func() {
+		fd := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil || reader == nil {
+				return
+			}
+			reader.Close()
+			scriptEntry.SetText(localPath(reader.URI()))
+		}, ui.window)
+		fd.SetFilter(storage.NewExtensionFileFilter([]string{".json"}))
+		fd.Show()
+	})
+	renderEntry := widget.NewEntry()
+	renderEntry.SetText("60")
+	autoStartCheck := widget.NewCheck("Start with the app", nil)
+	autoStartCheck.SetChecked(cfg.AutoStart)
+	statusLabel := widget.NewLabel("")
+
+	inputs := []fyne.Disableable{intervalEntry, columnsEntry, headerCheck, echoCheck, noiseEntry, burstEveryEntry, burstSizeEntry, seedEntry, scriptEntry, browseBtn}
+	var startBtn *widget.Button
+	setRunning := func(running bool) {
+		for _, w := range inputs {
+			if running {
+				w.Disable()
+			} else {
+				w.Enable()
+			}
+		}
+		switch {
+		case running && ui.simulator.Unplugged():
+			startBtn.SetText("Stop")
+			statusLabel.SetText("Unplugged by the device script")
+		case running:
+			startBtn.SetText("Stop")
+			statusLabel.SetText("Running on " + ui.simulator.PortName())
+		default:
+			startBtn.SetText("Start")
+			statusLabel.SetText("Stopped")
+		}
+	}
+
+	// read collects the settings from the form.
+	read := func() (SimulatorSettings, error) {
+		s := SimulatorSettings{
+			Header:    headerCheck.Checked,
+			Echo:      echoCheck.Checked,
+			AutoStart: autoStartCheck.Checked,
+			Script:    strings.TrimSpace(scriptEntry.Text),
+		}
+		var err error
+		if s.IntervalMS, err = strconv.Atoi(intervalEntry.Text); err != nil {
+			return s, fmt.Errorf("invalid interval: %s", intervalEntry.Text)
+		}
+		if s.Columns, err = strconv.Atoi(columnsEntry.Text); err != nil {
+			return s, fmt.Errorf("invalid column count: %s", columnsEntry.Text)
+		}
+		noise, err := strconv.ParseFloat(noiseEntry.Text, 64)
+		if err != nil {
+			return s, fmt.Errorf("invalid noise rate: %s", noiseEntry.Text)
+		}
+		s.NoiseRate = noise / 100
+		if s.BurstEvery, err = strconv.Atoi(burstEveryEntry.Text); err != nil {
+			return s, fmt.Errorf("invalid burst interval: %s", burstEveryEntry.Text)
+		}
+		if s.BurstSize, err = strconv.Atoi(burstSizeEntry.Text); err != nil {
+			return s, fmt.Errorf("invalid burst size: %s", burstSizeEntry.Text)
+		}
+		if s.Seed, err = strconv.ParseInt(seedEntry.Text, 10, 64); err != nil {
+			return s, fmt.Errorf("invalid seed: %s", seedEntry.Text)
+		}
+		return s, s.validate()
+	}
+
+	startBtn = widget.NewButton("Start", func() {
+		if ui.simulator != nil {
+			ui.stopSimulator()
+			setRunning(false)
+			return
+		}
+		s, err := read()
+		if err != nil {
+			dialog.ShowError(err, ui.window)
+			return
+		}
+		ui.settings.Simulator = s
+		ui.saveSettings()
+		if err := ui.startSimulator(s); err != nil {
+			dialog.ShowError(err, ui.window)
+			return
+		}
+		setRunning(true)
+	})
+	// Render plays the device on its virtual clock straight into the output,
+	// without a port, so a seeded device gives the same lines every time.
+	renderBtn := widget.NewButton("Render", func() {
+		if ui.connected.Load() {
+			dialog.ShowInformation("Render", "Disconnect first; rendered lines would mix with the port's.", ui.window)
+			return
+		}
+		secs, err := strconv.Atoi(renderEntry.Text)
+		if err != nil || secs <= 0 {
+			dialog.ShowError(fmt.Errorf("invalid duration: %s", renderEntry.Text), ui.window)
+			return
+		}
+		s, err := read()
+		if err != nil {
+			dialog.ShowError(err, ui.window)
+			return
+		}
+		script, err := s.script()
+		if err != nil {
+			dialog.ShowError(err, ui.window)
+			return
+		}
+		ui.settings.Simulator = s
+		ui.saveSettings()
+		// Stamp the lines so the last is due now rather than in the future.
+		duration := time.Duration(secs) * time.Second
+		for _, line := range RenderScript(script, duration, time.Now().Add(-duration)) {
+			ui.appendLine(line)
+		}
+	})
+	autoStartCheck.OnChanged = func(on bool) {
+		ui.settings.Simulator.AutoStart = on
+		ui.saveSettings()
+	}
+	setRunning(ui.simulator != nil)
+	if !simulatorSupported {
+		startBtn.Disable()
+		autoStartCheck.Disable()
+		statusLabel.SetText("Needs Linux")
+	}
+
+	form := widget.NewForm(
+		widget.NewFormItem("Line Interval (ms)", intervalEntry),
+		widget.NewFormItem("Sensor Columns", columnsEntry),
+		widget.NewFormItem("", headerCheck),
+		widget.NewFormItem("", echoCheck),
+		widget.NewFormItem("Noise (% of lines)", noiseEntry),
+		widget.NewFormItem("Burst Every (lines)", burstEveryEntry),
+		widget.NewFormItem("Burst Size (lines)", burstSizeEntry),
+		widget.NewFormItem("Random Seed", seedEntry),
+		widget.NewFormItem("Device Script", container.NewBorder(nil, nil, nil, browseBtn, scriptEntry)),
+		widget.NewFormItem("", autoStartCheck),
+		widget.NewFormItem("Status", statusLabel),
+	)
+	buttons := container.NewHBox(startBtn, layout.NewSpacer(), widget.NewLabel("Render seconds:"), renderEntry, renderBtn)
+	content := container.NewBorder(form, buttons, nil, nil)
+	d := dialog.NewCustom("Device Simulator", "Close", content, ui.window)
+	d.Resize(fyne.NewSize(480, 0))
+	d.Show()
+}
@@ -0,0 +1,521 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Sink kinds.
+const (
+	SinkLog    = "Rotating log"
+	SinkCSV    = "CSV stream"
+	SinkUDP    = "UDP"
+	SinkTCP    = "TCP"
+	SinkExec   = "Command"
+	SinkStdout = "Standard output"
+)
+
+var sinkKinds = []string{SinkLog, SinkCSV, SinkUDP, SinkTCP, SinkExec, SinkStdout}
+
+// Line formats for the text sinks. The CSV stream always writes CSV.
+const (
+	SinkFormatRaw         = "Raw"
+	SinkFormatTimestamped = "Timestamped"
+	SinkFormatJSON        = "JSON"
+)
+
+var sinkFormats = []string{SinkFormatRaw, SinkFormatTimestamped, SinkFormatJSON}
+
+// sinkQueueSize is how many lines a sink may fall behind before lines are
+// dropped for it.
+const sinkQueueSize = 4096
+
+// sinkDrainTimeout bounds how long closing waits for sinks to write their
+// queued lines, and for a command to exit once its input is closed.
+const sinkDrainTimeout = 2 * time.Second
+
+// SinkConfig describes one output sink.
+type SinkConfig struct {
+	Name      string `json:"name"`
+	Kind      string `json:"kind"` // one of sinkKinds
+	Enabled   bool   `json:"enabled"`
+	Target    string `json:"target"`           // file path, host:port, or command line; unused for stdout
+	Filter    string `json:"filter,omitempty"` // regular expression lines must match; all if empty
+	Format    string `json:"format,omitempty"` // one of sinkFormats; Raw if empty
+	MaxSizeMB int    `json:"maxSizeMB,omitempty"`
+	MaxFiles  int    `json:"maxFiles,omitempty"`
+}
+
+// validate checks a sink can be started.
+func (c SinkConfig) validate() error {
+	if strings.TrimSpace(c.Name) == "" {
+		return fmt.Errorf("sink has no name")
+	}
+	if c.Kind != SinkStdout && strings.TrimSpace(c.Target) == "" {
+		return fmt.Errorf("sink %q has no target", c.Name)
+	}
+	if _, err := regexp.Compile(c.Filter); err != nil {
+		return fmt.Errorf("sink %q: invalid filter: %w", c.Name, err)
+	}
+	return nil
+}
+
+// sinkWriter delivers lines to one destination.
+type sinkWriter interface {
+	WriteLine(line SerialLine) error
+	Close() error
+}
+
+// sinkAborter is implemented by sink writers whose WriteLine can block
+// indefinitely. Abort makes a blocked WriteLine return; it may be called while
+// WriteLine runs.
+type sinkAborter interface {
+	Abort()
+}
+
+// formatSinkLine renders a line in a text sink format, with a trailing newline.
+func formatSinkLine(line SerialLine, format string) []byte {
+	switch format {
+	case SinkFormatTimestamped:
+		return fmt.Appendf(nil, "[%s] %s\n", line.Timestamp.Format("2006-01-02 15:04:05.000000"), line.Data)
+	case SinkFormatJSON:
+		data, _ := json.Marshal(newAPILine(line))
+		return append(data, '\n')
+	}
+	return append([]byte(line.Data), '\n')
+}
+
+// rotatingLog appends lines to a file, renaming it to path.1, path.2, ... once
+// it exceeds maxSize, and keeping at most maxFiles old files.
+type rotatingLog struct {
+	path     string
+	format   string
+	maxSize  int64
+	maxFiles int
+	f        *os.File
+	size     int64
+}
+
+func openRotatingLog(cfg SinkConfig) (*rotatingLog, error) {
+	l := &rotatingLog{path: cfg.Target, format: cfg.Format, maxSize: int64(cfg.MaxSizeMB) << 20, maxFiles: cfg.MaxFiles}
+	if l.maxFiles <= 0 {
+		l.maxFiles = 5
+	}
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (l *rotatingLog) open() error {
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open log: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("failed to open log: %w", err)
+	}
+	l.f, l.size = f, info.Size()
+	return nil
+}
+
+func (l *rotatingLog) rotate() error {
+	l.f.Close()
+	os.Remove(fmt.Sprintf("%s.%d", l.path, l.maxFiles))
+	for i := l.maxFiles - 1; i >= 1; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", l.path, i), fmt.Sprintf("%s.%d", l.path, i+1))
+	}
+	if err := os.Rename(l.path, l.path+".1"); err != nil {
+		return fmt.Errorf("failed to rotate log: %w", err)
+	}
+	return l.open()
+}
+
+func (l *rotatingLog) WriteLine(line SerialLine) error {
+	if l.maxSize > 0 && l.size >= l.maxSize {
+		if err := l.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := l.f.Write(formatSinkLine(line, l.format))
+	l.size += int64(n)
+	return err
+}
+
+func (l *rotatingLog) Close() error {
+	return l.f.Close()
+}
+
+// csvStream appends each line as a CSV record of its timestamp and decoded
+// fields.
+type csvStream struct {
+	f       *os.File
+	w       *csv.Writer
+	decoder func() Decoder
+}
+
+func openCSVStream(cfg SinkConfig, decoder func() Decoder) (*csvStream, error) {
+	f, err := os.OpenFile(cfg.Target, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open CSV stream: %w", err)
+	}
+	return &csvStream{f: f, w: csv.NewWriter(f), decoder: decoder}, nil
+}
+
+func (s *csvStream) WriteLine(line SerialLine) error {
+	record := []string{line.Timestamp.Format("2006-01-02 15:04:05.000000")}
+	fields, err := s.decoder().Decode(line.Data)
+	if err != nil {
+		record = append(record, line.Data)
+	}
+	for _, f := range fields {
+		record = append(record, f.Value)
+	}
+	s.w.Write(record)
+	s.w.Flush()
+	return s.w.Error()
+}
+
+func (s *csvStream) Close() error {
+	s.w.Flush()
+	return s.f.Close()
+}
+
+// netForwarder sends each line to a UDP or TCP address. A TCP connection that
+// fails is redialled, at most every few seconds, when the next line arrives.
+type netForwarder struct {
+	network  string
+	addr     string
+	format   string
+	conn     net.Conn
+	lastDial time.Time
+}
+
+const netRedialInterval = 5 * time.Second
+
+// openNetForwarder only fails for a bad address; a listener that is not up
+// yet is retried as lines arrive.
+func openNetForwarder(network string, cfg SinkConfig) (*netForwarder, error) {
+	if _, _, err := net.SplitHostPort(cfg.Target); err != nil {
+		return nil, fmt.Errorf("invalid address %q: %w", cfg.Target, err)
+	}
+	f := &netForwarder{network: network, addr: cfg.Target, format: cfg.Format}
+	f.dial()
+	return f, nil
+}
+
+func (f *netForwarder) dial() error {
+	f.lastDial = time.Now()
+	conn, err := net.DialTimeout(f.network, f.addr, netRedialInterval)
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", f.addr, err)
+	}
+	f.conn = conn
+	return nil
+}
+
+func (f *netForwarder) WriteLine(line SerialLine) error {
+	if f.conn == nil {
+		if time.Since(f.lastDial) < netRedialInterval {
+			return fmt.Errorf("not connected to %s", f.addr)
+		}
+		if err := f.dial(); err != nil {
+			return err
+		}
+	}
+	f.conn.SetWriteDeadline(time.Now().Add(netRedialInterval))
+	if _, err := f.conn.Write(formatSinkLine(line, f.format)); err != nil {
+		f.conn.Close()
+		f.conn = nil
+		return fmt.Errorf("failed to send to %s: %w", f.addr, err)
+	}
+	return nil
+}
+
+func (f *netForwarder) Close() error {
+	if f.conn == nil {
+		return nil
+	}
+	return f.conn.Close()
+}
+
+// execPipe writes lines to a command's standard input. The command line is
+// run by the system shell, so it may contain pipes and redirection.
+type execPipe struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	format string
+}
+
+func openExecPipe(cfg SinkConfig) (*execPipe, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", cfg.Target)
+	} else {
+		cmd = exec.Command("sh", "-c", cfg.Target)
+	}
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start command: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start command: %w", err)
+	}
+	return &execPipe{cmd: cmd, stdin: stdin, format: cfg.Format}, nil
+}
+
+func (p *execPipe) WriteLine(line SerialLine) error {
+	if _, err := p.stdin.Write(formatSinkLine(line, p.format)); err != nil {
+		return fmt.Errorf("command stopped reading: %w", err)
+	}
+	return nil
+}
+
+// Close closes the command's input and waits for it to exit, killing it if
+// it keeps running.
+func (p *execPipe) Close() error {
+	p.stdin.Close()
+	exited := make(chan error, 1)
+	go func() { exited <- p.cmd.Wait() }()
+	select {
+	case err := <-exited:
+		return err
+	case <-time.After(sinkDrainTimeout):
+		p.cmd.Process.Kill()
+		<-exited
+		return fmt.Errorf("command didn't exit when its input closed and was killed")
+	}
+}
+
+// Abort kills the command and closes its input, so a write blocked on a
+// command that stopped reading fails.
+func (p *execPipe) Abort() {
+	p.cmd.Process.Kill()
+	p.stdin.Close()
+}
+
+// stdoutSink writes lines to the app's standard output, for piping the
+// monitor itself into another program.
+type stdoutSink struct {
+	format string
+}
+
+func (s stdoutSink) WriteLine(line SerialLine) error {
+	_, err := os.Stdout.Write(formatSinkLine(line, s.format))
+	return err
+}
+
+func (stdoutSink) Close() error {
+	return nil
+}
+
+// openSink starts the writer for a sink.
+func openSink(cfg SinkConfig, decoder func() Decoder) (sinkWriter, error) {
+	switch cfg.Kind {
+	case SinkLog:
+		return openRotatingLog(cfg)
+	case SinkCSV:
+		return openCSVStream(cfg, decoder)
+	case SinkUDP:
+		return openNetForwarder("udp", cfg)
+	case SinkTCP:
+		return openNetForwarder("tcp", cfg)
+	case SinkExec:
+		return openExecPipe(cfg)
+	case SinkStdout:
+		return stdoutSink{format: cfg.Format}, nil
+	}
+	return nil, fmt.Errorf("unknown sink kind %q", cfg.Kind)
+}
+
+// runningSink feeds one sink from its own queue, so a slow sink drops lines
+// instead of holding up the others or the serial reader.
+type runningSink struct {
+	cfg     SinkConfig
+	filter  *regexp.Regexp
+	w       sinkWriter
+	queue   chan SerialLine
+	stop    chan struct{} // closed when Close gives up on draining the queue
+	done    chan struct{}
+	written atomic.Uint64
+	dropped atomic.Uint64
+
+	errMu   sync.Mutex
+	lastErr error
+}
+
+func (s *runningSink) run() {
+	defer close(s.done)
+	for line := range s.queue {
+		select {
+		case <-s.stop:
+			continue
+		default:
+		}
+		if err := s.w.WriteLine(line); err != nil {
+			s.errMu.Lock()
+			s.lastErr = err
+			s.errMu.Unlock()
+			continue
+		}
+		s.written.Add(1)
+	}
+}
+
+// SinkStatus reports a sink's progress.
+type SinkStatus struct {
+	Name    string
+	Running bool
+	Written uint64
+	Dropped uint64
+	Err     error // last write or start error
+}
+
+// SinkPipeline delivers received lines to the configured sinks.
+type SinkPipeline struct {
+	configMu sync.Mutex // serializes Configure
+	mu       sync.Mutex
+	sinks    []*runningSink
+	startErr map[string]error // sinks that failed to start, by name
+	decoder  func() Decoder
+}
+
+// NewSinkPipeline returns an empty pipeline. decoder returns the decoder used
+// by sinks that split lines into fields.
+func NewSinkPipeline(decoder func() Decoder) *SinkPipeline {
+	return &SinkPipeline{decoder: decoder}
+}
+
+// Configure stops the running sinks and starts the enabled ones in cfgs.
+// Sinks that fail to start are reported by Status; the others still run.
+func (p *SinkPipeline) Configure(cfgs []SinkConfig) {
+	p.configMu.Lock()
+	defer p.configMu.Unlock()
+	p.Close()
+
+	var sinks []*runningSink
+	startErr := make(map[string]error)
+	for _, cfg := range cfgs {
+		if !cfg.Enabled {
+			continue
+		}
+		if err := cfg.validate(); err != nil {
+			startErr[cfg.Name] = err
+			continue
+		}
+		w, err := openSink(cfg, p.decoder)
+		if err != nil {
+			startErr[cfg.Name] = err
+			continue
+		}
+		s := &runningSink{cfg: cfg, w: w, queue: make(chan SerialLine, sinkQueueSize),
+			stop: make(chan struct{}), done: make(chan struct{})}
+		if cfg.Filter != "" {
+			s.filter = regexp.MustCompile(cfg.Filter) // checked by validate
+		}
+		go s.run()
+		sinks = append(sinks, s)
+	}
+
+	p.mu.Lock()
+	p.sinks, p.startErr = sinks, startErr
+	p.mu.Unlock()
+}
+
+// Publish queues a line for every sink whose filter it matches. It never
+// blocks; a sink whose queue is full drops the line. It returns how many
+// sinks dropped it.
+func (p *SinkPipeline) Publish(line SerialLine) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	dropped := 0
+	for _, s := range p.sinks {
+		if s.filter != nil && !s.filter.MatchString(line.Data) {
+			continue
+		}
+		select {
+		case s.queue <- line:
+		default:
+			s.dropped.Add(1)
+			dropped++
+		}
+	}
+	return dropped
+}
+
+// Status reports every sink in cfgs order, running or not.
+func (p *SinkPipeline) Status(cfgs []SinkConfig) []SinkStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	statuses := make([]SinkStatus, len(cfgs))
+	for i, cfg := range cfgs {
+		st := SinkStatus{Name: cfg.Name, Err: p.startErr[cfg.Name]}
+		for _, s := range p.sinks {
+			if s.cfg.Name == cfg.Name {
+				st.Running = true
+				st.Written = s.written.Load()
+				st.Dropped = s.dropped.Load()
+				s.errMu.Lock()
+				st.Err = s.lastErr
+				s.errMu.Unlock()
+			}
+		}
+		statuses[i] = st
+	}
+	return statuses
+}
+
+// Close stops every sink after it has written its queued lines, waiting at
+// most sinkDrainTimeout. Sinks still writing then drop their remaining lines
+// and are aborted; one that stays stuck is closed in the background, so it
+// can't hold up reconfiguring or quitting.
+func (p *SinkPipeline) Close() {
+	p.mu.Lock()
+	sinks := p.sinks
+	p.sinks = nil
+	p.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), sinkDrainTimeout)
+	defer cancel()
+	var wg sync.WaitGroup
+	for _, s := range sinks {
+		close(s.queue)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case <-s.done:
+			case <-ctx.Done():
+				close(s.stop)
+				if a, ok := s.w.(sinkAborter); ok {
+					a.Abort()
+				}
+				select {
+				case <-s.done:
+				case <-time.After(sinkDrainTimeout):
+					go func() {
+						<-s.done
+						s.w.Close()
+					}()
+					return
+				}
+			}
+			s.w.Close()
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSinkCloseDoesNotWaitOnStuckCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell command")
+	}
+	p := NewSinkPipeline(func() Decoder { return csvDecoder{} })
+	// sleep never reads its input, so writes block once the pipe is full.
+	p.Configure([]SinkConfig{{Name: "stuck", Kind: SinkExec, Enabled: true, Target: "sleep 60"}})
+	if st := p.Status([]SinkConfig{{Name: "stuck"}}); !st[0].Running {
+		t.Fatalf("sink didn't start: %v", st[0].Err)
+	}
+	line := SerialLine{Timestamp: time.Now(), Data: strings.Repeat("x", 1024)}
+	for range 1000 {
+		p.Publish(line)
+	}
+
+	closed := make(chan struct{})
+	go func() {
+		p.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(3 * sinkDrainTimeout):
+		t.Fatal("Close waited on a sink that stopped reading")
+	}
+}
+
+func TestSinkCloseKillsCommandIgnoringEOF(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell command")
+	}
+	w, err := openExecPipe(SinkConfig{Target: "sleep 60"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteLine(SerialLine{Data: "hello"}); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	if err := w.Close(); err == nil {
+		t.Error("Close reported a clean exit for a killed command")
+	}
+	if elapsed := time.Since(start); elapsed > 2*sinkDrainTimeout {
+		t.Errorf("Close took %s", elapsed)
+	}
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// applySinks restarts the sink pipeline with the saved sinks. Stopping sinks
+// waits for their queues to drain, so it runs off the UI thread.
+func (ui *AppUI) applySinks() {
+	cfgs := slices.Clone(ui.settings.Sinks)
+	go ui.sinks.Configure(cfgs)
+}
+
+// showSinksDialog lists the output sinks with their progress and edits them.
+func (ui *AppUI) showSinksDialog() {
+	selected := -1
+	var statuses []SinkStatus
+
+	list := widget.NewList(
+		func() int { return len(ui.settings.Sinks) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			if id >= len(ui.settings.Sinks) {
+				return
+			}
+			cfg := ui.settings.Sinks[id]
+			text := fmt.Sprintf("%s (%s)  off", cfg.Name, cfg.Kind)
+			if id < len(statuses) {
+				st := statuses[id]
+				switch {
+				case st.Running:
+					text = fmt.Sprintf("%s (%s)  %d written, %d dropped", cfg.Name, cfg.Kind, st.Written, st.Dropped)
+				case cfg.Enabled:
+					text = fmt.Sprintf("%s (%s)  not running", cfg.Name, cfg.Kind)
+				}
+				if st.Err != nil {
+					text += "  error: " + st.Err.Error()
+				}
+			}
+			obj.(*widget.Label).SetText(text)
+		},
+	)
+	list.OnSelected = func(id widget.ListItemID) { selected = id }
+
+	refresh := func() {
+		statuses = ui.sinks.Status(ui.settings.Sinks)
+		list.Refresh()
+	}
+	changed := func() {
+		ui.saveSettings()
+		ui.applySinks()
+		refresh()
+	}
+
+	addBtn := widget.NewButton("Add...", func() {
+		ui.showSinkEditor(SinkConfig{Kind: SinkLog, Enabled: true, Format: SinkFormatTimestamped, MaxSizeMB: 10, MaxFiles: 5}, func(cfg SinkConfig) error {
+			if slices.ContainsFunc(ui.settings.Sinks, func(s SinkConfig) bool { return s.Name == cfg.Name }) {
+				return fmt.Errorf("a sink named %q already exists", cfg.Name)
+			}
+			ui.settings.Sinks = append(ui.settings.Sinks, cfg)
+			changed()
+			return nil
+		})
+	})
+	editBtn := widget.NewButton("Edit...", func() {
+		if selected < 0 || selected >= len(ui.settings.Sinks) {
+			return
+		}
+		i := selected
+		ui.showSinkEditor(ui.settings.Sinks[i], func(cfg SinkConfig) error {
+			for j, s := range ui.settings.Sinks {
+				if j != i && s.Name == cfg.Name {
+					return fmt.Errorf("a sink named %q already exists", cfg.Name)
+				}
+			}
+			ui.settings.Sinks[i] = cfg
+			changed()
+			return nil
+		})
+	})
+	toggleBtn := widget.NewButton("Enable/Disable", func() {
+		if selected < 0 || selected >= len(ui.settings.Sinks) {
+			return
+		}
+		ui.settings.Sinks[selected].Enabled = !ui.settings.Sinks[selected].Enabled
+		changed()
+	})
+	removeBtn := widget.NewButton("Remove", func() {
+		if selected < 0 || selected >= len(ui.settings.Sinks) {
+			return
+		}
+		ui.settings.Sinks = slices.Delete(ui.settings.Sinks, selected, selected+1)
+		selected = -1
+		list.UnselectAll()
+		changed()
+	})
+	refresh()
+
+	// Keep the counters current while the dialog is open.
+	ticker := time.NewTicker(time.Second)
+	stop := make(chan struct{})
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				fyne.Do(refresh)
+			}
+		}
+	}()
+
+	listScroll := container.NewVScroll(list)
+	listScroll.SetMinSize(fyne.NewSize(560, 200))
+	buttons := container.NewHBox(addBtn, editBtn, toggleBtn, removeBtn)
+	d := dialog.NewCustom("Output Sinks", "Close", container.NewBorder(nil, buttons, nil, nil, listScroll), ui.window)
+	d.SetOnClosed(func() { close(stop) })
+	d.Show()
+}
+
+// showSinkEditor edits one sink's settings. save may reject the result, for
+// example when its name is taken.
+func (ui *AppUI) showSinkEditor(cfg SinkConfig, save func(SinkConfig) error) {
+	nameEntry := widget.NewEntry()
+	nameEntry.SetText(cfg.Name)
+	targetEntry := widget.NewEntry()
+	targetEntry.SetText(cfg.Target)
+	filterEntry := widget.NewEntry()
+	filterEntry.SetPlaceHolder("regular expression; all lines if empty")
+	filterEntry.SetText(cfg.Filter)
+	formatSelect := widget.NewSelect(sinkFormats, nil)
+	formatSelect.SetSelected(cmp.Or(cfg.Format, SinkFormatRaw))
+	maxSizeEntry := widget.NewEntry()
+	maxSizeEntry.SetText(strconv.Itoa(cfg.MaxSizeMB))
+	maxFilesEntry := widget.NewEntry()
+	maxFilesEntry.SetText(strconv.Itoa(cfg.MaxFiles))
+	enabledCheck := widget.NewCheck("Enabled", nil)
+	enabledCheck.SetChecked(cfg.Enabled)
+
+	kindSelect := widget.NewSelect(sinkKinds, func(kind string) {
+		placeholders := map[string]string{
+			SinkLog:    "log file path",
+			SinkCSV:    "CSV file path",
+			SinkUDP:    "host:port",
+			SinkTCP:    "host:port",
+			SinkExec:   "command line, e.g. python3 plot.py",
+			SinkStdout: "",
+		}
+		targetEntry.SetPlaceHolder(placeholders[kind])
+		if kind == SinkStdout {
+			targetEntry.Disable()
+		} else {
+			targetEntry.Enable()
+		}
+		if kind == SinkLog {
+			maxSizeEntry.Enable()
+			maxFilesEntry.Enable()
+		} else {
+			maxSizeEntry.Disable()
+			maxFilesEntry.Disable()
+		}
+		if kind == SinkCSV {
+			formatSelect.Disable()
+		} else {
+			formatSelect.Enable()
+		}
+	})
+	kindSelect.SetSelected(cfg.Kind)
+
+	form := []*widget.FormItem{
+		widget.NewFormItem("Name", nameEntry),
+		widget.NewFormItem("Kind", kindSelect),
+		widget.NewFormItem("Target", targetEntry),
+		widget.NewFormItem("Filter", filterEntry),
+		widget.NewFormItem("Format", formatSelect),
+		widget.NewFormItem("Rotate at (MB)", maxSizeEntry),
+		widget.NewFormItem("Files kept", maxFilesEntry),
+		widget.NewFormItem("", enabledCheck),
+	}
+	d := dialog.NewForm("Sink", "Save", "Cancel", form, func(ok bool) {
+		if !ok {
+			return
+		}
+		maxSize, _ := strconv.Atoi(maxSizeEntry.Text)
+		maxFiles, _ := strconv.Atoi(maxFilesEntry.Text)
+		edited := SinkConfig{
+			Name:      strings.TrimSpace(nameEntry.Text),
+			Kind:      kindSelect.Selected,
+			Enabled:   enabledCheck.Checked,
+			Target:    strings.TrimSpace(targetEntry.Text),
+			Filter:    filterEntry.Text,
+			Format:    formatSelect.Selected,
+			MaxSizeMB: max(maxSize, 0),
+			MaxFiles:  max(maxFiles, 0),
+		}
+		err := edited.validate()
+		if err == nil {
+			err = save(edited)
+		}
+		if err != nil {
+			dialog.ShowError(err, ui.window)
+		}
+	}, ui.window)
+	d.Resize(fyne.NewSize(480, 0))
+	d.Show()
+}
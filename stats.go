@@ -0,0 +1,188 @@
+package main
+
+import (
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// statsHistory is how many seconds of throughput the sparkline shows.
+const statsHistory = 60
+
+// LineStats accumulates link health figures for received lines. Throughput
+// is kept in one-second buckets so recent rates and their history are cheap.
+type LineStats struct {
+	mu sync.Mutex
+	c  lineCounts
+}
+
+// lineCounts are LineStats' figures, cleared together on reset.
+type lineCounts struct {
+	since        time.Time // start of the current counting period
+	lines        uint64
+	bytes        uint64
+	longest      int
+	lastLine     time.Time
+	maxGap       time.Duration
+	invalidUTF8  uint64
+	nonPrintable uint64
+	drops        uint64
+	lag          time.Duration // from a line's arrival to the consumer taking it
+	maxLag       time.Duration
+
+	bucketSec   int64 // Unix second of buckets[0]
+	lineBuckets [statsHistory + 1]uint64
+	byteBuckets [statsHistory + 1]uint64
+}
+
+// StatsSnapshot is a copy of the figures at one moment.
+type StatsSnapshot struct {
+	Since        time.Time     `json:"since"`
+	Lines        uint64        `json:"lines"`
+	Bytes        uint64        `json:"bytes"`
+	BytesPerSec  float64       `json:"bytesPerSec"` // over the last complete second
+	LinesPerSec  float64       `json:"linesPerSec"`
+	Longest      int           `json:"longestLine"`
+	AverageLen   float64       `json:"averageLine"`
+	MaxGap       time.Duration `json:"maxGap"`
+	InvalidUTF8  uint64        `json:"invalidUtf8"`
+	NonPrintable uint64        `json:"nonPrintable"`
+	Drops        uint64        `json:"drops"`
+	Lag          time.Duration `json:"lag"` // of the latest line
+	MaxLag       time.Duration `json:"maxLag"`
+	History      []uint64      `json:"-"` // lines per second, oldest first
+}
+
+// NewLineStats returns empty statistics counting from now.
+func NewLineStats() *LineStats {
+	s := &LineStats{}
+	s.Reset()
+	return s
+}
+
+// Reset clears every figure and starts counting again.
+func (s *LineStats) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	s.c = lineCounts{since: now, bucketSec: now.Unix() - statsHistory}
+}
+
+// Restore replaces the figures with saved ones, such as a session's, and
+// keeps counting from them. Throughput history isn't restored.
+func (s *LineStats) Restore(snap StatsSnapshot) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	s.c = lineCounts{
+		since:        snap.Since,
+		lines:        snap.Lines,
+		bytes:        snap.Bytes,
+		longest:      snap.Longest,
+		maxGap:       snap.MaxGap,
+		invalidUTF8:  snap.InvalidUTF8,
+		nonPrintable: snap.NonPrintable,
+		drops:        snap.Drops,
+		maxLag:       snap.MaxLag,
+		bucketSec:    now.Unix() - statsHistory,
+	}
+}
+
+// advanceLocked shifts the buckets so the last one is the second now falls
+// in. Must be called with s.mu held.
+func (s *LineStats) advanceLocked(now time.Time) {
+	last := s.c.bucketSec + statsHistory
+	shift := now.Unix() - last
+	if shift <= 0 {
+		return
+	}
+	if shift > statsHistory {
+		shift = statsHistory + 1
+	}
+	n := copy(s.c.lineBuckets[:], s.c.lineBuckets[shift:])
+	clear(s.c.lineBuckets[n:])
+	copy(s.c.byteBuckets[:], s.c.byteBuckets[shift:])
+	clear(s.c.byteBuckets[n:])
+	s.c.bucketSec = now.Unix() - statsHistory
+}
+
+// Add counts a received line as the consumer takes it. Its size includes the
+// delimiter.
+func (s *LineStats) Add(line SerialLine) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	s.advanceLocked(now)
+	s.c.lag = now.Sub(line.Timestamp)
+	s.c.maxLag = max(s.c.maxLag, s.c.lag)
+	size := len(line.Data) + 1
+	s.c.lines++
+	s.c.bytes += uint64(size)
+	s.c.lineBuckets[statsHistory]++
+	s.c.byteBuckets[statsHistory] += uint64(size)
+	s.c.longest = max(s.c.longest, len(line.Data))
+	if !s.c.lastLine.IsZero() {
+		s.c.maxGap = max(s.c.maxGap, line.Timestamp.Sub(s.c.lastLine))
+	}
+	s.c.lastLine = line.Timestamp
+	if !utf8.ValidString(line.Data) {
+		s.c.invalidUTF8++
+	} else if !printable(line.Data) {
+		s.c.nonPrintable++
+	}
+}
+
+// Reconnected starts a new connection, so the time spent disconnected isn't
+// counted as a gap between lines.
+func (s *LineStats) Reconnected() {
+	s.mu.Lock()
+	s.c.lastLine = time.Time{}
+	s.mu.Unlock()
+}
+
+// Drop counts lines lost because a consumer's buffer was full.
+func (s *LineStats) Drop(n int) {
+	if n == 0 {
+		return
+	}
+	s.mu.Lock()
+	s.c.drops += uint64(n)
+	s.mu.Unlock()
+}
+
+// Snapshot returns the current figures.
+func (s *LineStats) Snapshot() StatsSnapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.advanceLocked(time.Now())
+	snap := StatsSnapshot{
+		Since:        s.c.since,
+		Lines:        s.c.lines,
+		Bytes:        s.c.bytes,
+		BytesPerSec:  float64(s.c.byteBuckets[statsHistory-1]),
+		LinesPerSec:  float64(s.c.lineBuckets[statsHistory-1]),
+		Longest:      s.c.longest,
+		MaxGap:       s.c.maxGap,
+		InvalidUTF8:  s.c.invalidUTF8,
+		NonPrintable: s.c.nonPrintable,
+		Drops:        s.c.drops,
+		Lag:          s.c.lag,
+		MaxLag:       s.c.maxLag,
+		History:      append([]uint64(nil), s.c.lineBuckets[:statsHistory]...),
+	}
+	if s.c.lines > 0 {
+		snap.AverageLen = float64(s.c.bytes-s.c.lines) / float64(s.c.lines)
+	}
+	return snap
+}
+
+// printable reports whether a line has no control characters other than tabs.
+func printable(s string) bool {
+	for _, r := range s {
+		if r != '\t' && !unicode.IsPrint(r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"slices"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// newSparkline returns a raster drawing values as bars scaled to the largest.
+// Refresh it after changing *values.
+func newSparkline(values *[]uint64) *canvas.Raster {
+	r := canvas.NewRaster(func(w, h int) image.Image {
+		img := image.NewRGBA(image.Rect(0, 0, w, h))
+		vals := *values
+		if len(vals) == 0 || w == 0 || h == 0 {
+			return img
+		}
+		peak := max(slices.Max(vals), 1)
+		fill := image.NewUniform(theme.Color(theme.ColorNamePrimary))
+		for i, v := range vals {
+			x0, x1 := i*w/len(vals), (i+1)*w/len(vals)
+			top := h - int(float64(h)*float64(v)/float64(peak))
+			if v > 0 && top >= h {
+				top = h - 1 // keep small values visible
+			}
+			draw.Draw(img, image.Rect(x0, top, max(x1-1, x0+1), h), fill, image.Point{}, draw.Src)
+		}
+		return img
+	})
+	r.SetMinSize(fyne.NewSize(360, 48))
+	return r
+}
+
+// showStatsDialog shows throughput and error counts for lines read from the
+// port, refreshed every second.
+func (ui *AppUI) showStatsDialog() {
+	summary := widget.NewLabel("")
+	summary.TextStyle = fyne.TextStyle{Monospace: true}
+	var history []uint64
+	spark := newSparkline(&history)
+	caption := widget.NewLabel("")
+	health := widget.NewLabel("")
+	health.TextStyle = fyne.TextStyle{Monospace: true}
+	policySelect := widget.NewSelect(backpressurePolicies, func(policy string) {
+		ui.settings.Connection.Backpressure = policy
+		ui.serial.SetBackpressure(policy)
+		ui.saveSettings()
+	})
+	policySelect.SetSelected(ui.settings.Connection.Backpressure)
+
+	update := func() {
+		st := ui.stats.Snapshot()
+		summary.SetText(fmt.Sprintf(
+			"Since:          %s\n"+
+				"Throughput:     %.0f B/s, %.0f lines/s\n"+
+				"Total:          %d bytes, %d lines\n"+
+				"Line length:    longest %d, average %.1f bytes\n"+
+				"Longest gap:    %s\n"+
+				"Invalid UTF-8:  %d lines\n"+
+				"Non-printable:  %d lines\n"+
+				"Buffer drops:   %d lines\n"+
+				"Display lag:    %s (longest %s)",
+			st.Since.Format("15:04:05"),
+			st.BytesPerSec, st.LinesPerSec,
+			st.Bytes, st.Lines,
+			st.Longest, st.AverageLen,
+			formatGap(st.MaxGap),
+			st.InvalidUTF8, st.NonPrintable, st.Drops,
+			st.Lag.Round(time.Millisecond), st.MaxLag.Round(time.Millisecond)))
+		health.SetText(formatHealth(ui.serial.Health()))
+		history = st.History
+		caption.SetText(fmt.Sprintf("Lines per second, last %d s (peak %d)", statsHistory, slices.Max(history)))
+		spark.Refresh()
+	}
+	resetBtn := widget.NewButton("Reset", func() {
+		ui.stats.Reset()
+		update()
+	})
+	update()
+
+	ticker := time.NewTicker(time.Second)
+	stop := make(chan struct{})
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				fyne.Do(update)
+			}
+		}
+	}()
+
+	frame := canvas.NewRectangle(color.Transparent)
+	frame.StrokeColor = theme.Color(theme.ColorNameSeparator)
+	frame.StrokeWidth = 1
+	policyRow := container.NewHBox(widget.NewLabel("When the display falls behind:"), policySelect, widget.NewLabel("(applies on connect)"))
+	content := container.NewVBox(summary, caption, container.NewStack(frame, spark), container.NewHBox(resetBtn),
+		widget.NewSeparator(), policyRow, health)
+	d := dialog.NewCustom("Line Statistics", "Close", content, ui.window)
+	d.SetOnClosed(func() { close(stop) })
+	d.Show()
+}
+
+// formatHealth renders the reader's backpressure and driver error counts.
+func formatHealth(h LinkHealth) string {
+	text := fmt.Sprintf(
+		"Buffer full:    %d times, reader waited %s\n"+
+			"Backlog:        %d lines queued, %d spooled on disk\n"+
+			"Policy losses:  %d dropped, %d spilled",
+		h.ChannelFull, h.Stalled.Round(time.Millisecond),
+		h.Backlog, h.Spooled,
+		h.Dropped, h.Spilled)
+	if h.OSErr != nil {
+		return text + "\nDriver errors:  unavailable (" + h.OSErr.Error() + ")"
+	}
+	return text + fmt.Sprintf("\nDriver errors:  %d overrun, %d buffer overrun, %d framing, %d parity, %d break",
+		h.OS.Overrun, h.OS.BufferOverrun, h.OS.Frame, h.OS.Parity, h.OS.Break)
+}
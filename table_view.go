@@ -0,0 +1,519 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	tableHeaderFieldNames = "Field names"
+	statsInterval         = 500 * time.Millisecond
+)
+
+// columnStats keeps running figures for the numeric values in one column, so
+// they can be shown without rescanning the table. The min and max candidates
+// are kept in row order, which makes dropping the oldest rows cheap.
+type columnStats struct {
+	count      int
+	sum        float64
+	mins, maxs []statPoint
+}
+
+// statPoint is a value and the row it came from, counted from the first row
+// ever added.
+type statPoint struct {
+	row int
+	v   float64
+}
+
+func (s *columnStats) add(row int, v float64) {
+	s.count++
+	s.sum += v
+	for len(s.mins) > 0 && s.mins[len(s.mins)-1].v >= v {
+		s.mins = s.mins[:len(s.mins)-1]
+	}
+	s.mins = append(s.mins, statPoint{row, v})
+	for len(s.maxs) > 0 && s.maxs[len(s.maxs)-1].v <= v {
+		s.maxs = s.maxs[:len(s.maxs)-1]
+	}
+	s.maxs = append(s.maxs, statPoint{row, v})
+}
+
+// remove forgets the value of the oldest row still counted.
+func (s *columnStats) remove(row int, v float64) {
+	s.count--
+	s.sum -= v
+	if len(s.mins) > 0 && s.mins[0].row == row {
+		s.mins = s.mins[1:]
+	}
+	if len(s.maxs) > 0 && s.maxs[0].row == row {
+		s.maxs = s.maxs[1:]
+	}
+}
+
+// tableView shows decoded fields as sortable columns, as an alternative to the
+// line list. Its data is guarded by ui.mu alongside ui.lines.
+type tableView struct {
+	ui           *AppUI
+	table        *widget.Table
+	statsLabel   *widget.Label
+	headerSelect *widget.Select
+	content      fyne.CanvasObject
+
+	rows     []DecodedLine
+	columns  []string        // decoded field names in order of first appearance
+	template []string        // header template overriding column names by position
+	hidden   map[string]bool // columns hidden by the user
+	visible  []int           // indices into columns that are shown
+	sortCol  int             // index into columns, or -1 for arrival order
+	sortDesc bool
+	order    []int     // row numbers, counted like firstRow, in display order
+	keys     []sortKey // sort column value of each row while sorted
+
+	stats    map[string]*columnStats // by column name
+	firstRow int                     // number of rows ever dropped from the front
+	lastSeq  uint64                  // Seq of the newest line the table has taken
+
+	statsTime    time.Time
+	statsPending bool // a deferred stats update is scheduled
+}
+
+func newTableView(ui *AppUI) *tableView {
+	tv := &tableView{
+		ui:      ui,
+		hidden:  make(map[string]bool),
+		sortCol: -1,
+		stats:   make(map[string]*columnStats),
+	}
+
+	tv.table = widget.NewTableWithHeaders(
+		func() (int, int) {
+			ui.mu.Lock()
+			defer ui.mu.Unlock()
+			return len(tv.order), len(tv.visible)
+		},
+		func() fyne.CanvasObject {
+			label := widget.NewLabel("00000000000")
+			label.TextStyle = fyne.TextStyle{Monospace: true}
+			label.Truncation = fyne.TextTruncateEllipsis
+			return label
+		},
+		func(id widget.TableCellID, obj fyne.CanvasObject) {
+			ui.mu.Lock()
+			text := tv.cellLocked(id.Row, id.Col)
+			ui.mu.Unlock()
+			obj.(*widget.Label).SetText(text)
+		},
+	)
+	tv.table.ShowHeaderColumn = false
+	tv.table.CreateHeader = func() fyne.CanvasObject {
+		return widget.NewButton("", nil)
+	}
+	tv.table.UpdateHeader = func(id widget.TableCellID, obj fyne.CanvasObject) {
+		btn := obj.(*widget.Button)
+		ui.mu.Lock()
+		text := tv.headerLocked(id.Col)
+		ui.mu.Unlock()
+		btn.SetText(text)
+		col := id.Col
+		btn.OnTapped = func() {
+			tv.toggleSort(col)
+		}
+	}
+
+	tv.headerSelect = widget.NewSelect(nil, func(string) {
+		tv.applyHeaderTemplate()
+	})
+	tv.refreshTemplates()
+
+	templatesBtn := widget.NewButton("Templates...", func() {
+		ui.showTemplateEditor(nil)
+	})
+	columnsBtn := widget.NewButton("Columns...", func() {
+		tv.showColumnsDialog()
+	})
+	resetSortBtn := widget.NewButton("Arrival Order", func() {
+		ui.mu.Lock()
+		tv.sortCol = -1
+		tv.sortDesc = false
+		tv.resortLocked()
+		ui.mu.Unlock()
+		tv.table.Refresh()
+	})
+
+	tv.statsLabel = widget.NewLabel("")
+	tv.statsLabel.TextStyle = fyne.TextStyle{Monospace: true}
+	tv.statsLabel.Wrapping = fyne.TextWrapWord
+
+	controls := container.NewHBox(
+		widget.NewLabel("Header:"), tv.headerSelect, templatesBtn, columnsBtn, resetSortBtn)
+	tv.content = container.NewBorder(controls, tv.statsLabel, nil, nil, tv.table)
+	return tv
+}
+
+// refreshTemplates reloads the header template choices from the saved templates.
+func (tv *tableView) refreshTemplates() {
+	selected := tv.headerSelect.Selected
+	tv.headerSelect.Options = append([]string{tableHeaderFieldNames}, templateNames(tv.ui.savedTemplates)...)
+	if selected == "" || !slices.Contains(tv.headerSelect.Options, selected) {
+		selected = tableHeaderFieldNames
+	}
+	tv.headerSelect.SetSelected(selected)
+}
+
+func (tv *tableView) applyHeaderTemplate() {
+	var template []string
+	if t, ok := templateByName(tv.ui.savedTemplates, tv.headerSelect.Selected); ok {
+		template = t.Header()
+	}
+	tv.ui.mu.Lock()
+	tv.template = template
+	tv.ui.mu.Unlock()
+	tv.table.Refresh()
+}
+
+// rebuildLocked re-decodes every shown line, e.g. after the decoder changes.
+// While paused, that is the frozen snapshot. Must be called with ui.mu held.
+func (tv *tableView) rebuildLocked() {
+	lines, _ := tv.ui.visibleLocked()
+	tv.lastSeq = 0
+	if len(lines) > 0 {
+		tv.lastSeq = lines[len(lines)-1].Seq
+	}
+	tv.rows, tv.columns = decodeLines(lines, tv.ui.decoder, tv.ui.encoding)
+	tv.stats = make(map[string]*columnStats)
+	tv.firstRow = 0
+	for i, row := range tv.rows {
+		tv.countRowLocked(i, row.Fields, true)
+	}
+	tv.updateVisibleLocked()
+	tv.resortLocked()
+	tv.statsTime = time.Time{}
+}
+
+// addLocked decodes a newly received line and appends it to the table.
+// Must be called with ui.mu held.
+func (tv *tableView) addLocked(line SerialLine) {
+	tv.lastSeq = line.Seq
+	text := line.Data
+	if !isBinaryDecoder(tv.ui.decoder) {
+		text = decodeText(line.Data, tv.ui.encoding)
+	}
+	fields, err := tv.ui.decoder.Decode(text)
+	if err != nil {
+		return
+	}
+	grew := false
+	for _, f := range fields {
+		if !slices.Contains(tv.columns, f.Name) {
+			tv.columns = append(tv.columns, f.Name)
+			grew = true
+		}
+	}
+	if grew {
+		tv.updateVisibleLocked()
+	}
+
+	tv.rows = append(tv.rows, DecodedLine{Line: line, Text: text, Fields: fields})
+	id := tv.firstRow + len(tv.rows) - 1
+	tv.countRowLocked(id, fields, true)
+	if tv.sorted() {
+		tv.keys = append(tv.keys, newSortKey(fieldValue(fields, tv.columns[tv.sortCol])))
+		pos := sort.Search(len(tv.order), func(i int) bool { return tv.compareRowsLocked(tv.order[i], id) > 0 })
+		tv.order = slices.Insert(tv.order, pos, id)
+	} else {
+		tv.order = append(tv.order, id)
+	}
+
+	if len(tv.rows) > maxLines {
+		drop := len(tv.rows) - maxLines
+		for i, row := range tv.rows[:drop] {
+			tv.countRowLocked(tv.firstRow+i, row.Fields, false)
+			tv.unorderLocked(tv.firstRow + i)
+		}
+		tv.firstRow += drop
+		tv.rows = tv.rows[drop:]
+		if tv.sorted() {
+			tv.keys = tv.keys[drop:]
+		}
+	}
+}
+
+// catchUpLocked adds the buffered lines the table hasn't taken, such as those
+// received while paused. Must be called with ui.mu held.
+func (tv *tableView) catchUpLocked() {
+	lines := tv.ui.lines
+	i := sort.Search(len(lines), func(i int) bool { return lines[i].Seq > tv.lastSeq })
+	for _, line := range lines[i:] {
+		tv.addLocked(line)
+	}
+}
+
+// unorderLocked removes a row from the display order before it is dropped.
+// Must be called with ui.mu held.
+func (tv *tableView) unorderLocked(id int) {
+	if !tv.sorted() {
+		// Arrival order: the oldest row is first.
+		if len(tv.order) > 0 && tv.order[0] == id {
+			tv.order = tv.order[1:]
+		}
+		return
+	}
+	pos := sort.Search(len(tv.order), func(i int) bool { return tv.compareRowsLocked(tv.order[i], id) >= 0 })
+	if pos < len(tv.order) && tv.order[pos] == id {
+		tv.order = slices.Delete(tv.order, pos, pos+1)
+		return
+	}
+	// Mixed numbers and text don't order consistently; search the hard way.
+	tv.order = slices.DeleteFunc(tv.order, func(o int) bool { return o == id })
+}
+
+// countRowLocked adds a row's numeric fields to the column stats, or removes
+// them when the row is dropped. Must be called with ui.mu held.
+func (tv *tableView) countRowLocked(row int, fields []Field, add bool) {
+	for i, f := range fields {
+		// Like fieldValue, only the first field with a name counts.
+		if slices.ContainsFunc(fields[:i], func(g Field) bool { return g.Name == f.Name }) {
+			continue
+		}
+		v, err := strconv.ParseFloat(strings.TrimSpace(f.Value), 64)
+		if err != nil {
+			continue
+		}
+		st := tv.stats[f.Name]
+		if st == nil {
+			st = &columnStats{}
+			tv.stats[f.Name] = st
+		}
+		if add {
+			st.add(row, v)
+		} else {
+			st.remove(row, v)
+		}
+	}
+}
+
+func (tv *tableView) updateVisibleLocked() {
+	tv.visible = tv.visible[:0]
+	for i, name := range tv.columns {
+		if !tv.hidden[name] {
+			tv.visible = append(tv.visible, i)
+		}
+	}
+}
+
+// titleLocked returns the display name of a column, taken from the header
+// template when one is chosen.
+func (tv *tableView) titleLocked(col int) string {
+	if col < len(tv.template) && tv.template[col] != "" {
+		return tv.template[col]
+	}
+	return tv.columns[col]
+}
+
+// headerLocked returns the header text, with a sort marker, for a visible column.
+func (tv *tableView) headerLocked(visibleCol int) string {
+	if visibleCol < 0 || visibleCol >= len(tv.visible) {
+		return ""
+	}
+	col := tv.visible[visibleCol]
+	name := tv.titleLocked(col)
+	if col == tv.sortCol {
+		if tv.sortDesc {
+			name += " ▼"
+		} else {
+			name += " ▲"
+		}
+	}
+	return name
+}
+
+func (tv *tableView) cellLocked(row, visibleCol int) string {
+	if row >= len(tv.order) || visibleCol >= len(tv.visible) {
+		return ""
+	}
+	return fieldValue(tv.rows[tv.order[row]-tv.firstRow].Fields, tv.columns[tv.visible[visibleCol]])
+}
+
+func (tv *tableView) toggleSort(visibleCol int) {
+	tv.ui.mu.Lock()
+	if visibleCol < len(tv.visible) {
+		col := tv.visible[visibleCol]
+		if tv.sortCol == col {
+			tv.sortDesc = !tv.sortDesc
+		} else {
+			tv.sortCol = col
+			tv.sortDesc = false
+		}
+		tv.resortLocked()
+	}
+	tv.ui.mu.Unlock()
+	tv.table.Refresh()
+}
+
+// sorted reports whether rows are shown sorted by a column.
+func (tv *tableView) sorted() bool {
+	return tv.sortCol >= 0 && tv.sortCol < len(tv.columns)
+}
+
+// resortLocked recomputes the display order. Numbers sort numerically, other
+// values lexically, and equal values in arrival order. Must be called with
+// ui.mu held.
+func (tv *tableView) resortLocked() {
+	tv.order = make([]int, len(tv.rows))
+	for i := range tv.order {
+		tv.order[i] = tv.firstRow + i
+	}
+	tv.keys = nil
+	if !tv.sorted() {
+		return
+	}
+
+	name := tv.columns[tv.sortCol]
+	tv.keys = make([]sortKey, len(tv.rows))
+	for i, row := range tv.rows {
+		tv.keys[i] = newSortKey(fieldValue(row.Fields, name))
+	}
+	slices.SortStableFunc(tv.order, tv.compareRowsLocked)
+}
+
+// compareRowsLocked orders two rows, by number counted like firstRow, for
+// display. Must be called with ui.mu held.
+func (tv *tableView) compareRowsLocked(a, b int) int {
+	c := tv.keys[a-tv.firstRow].compare(tv.keys[b-tv.firstRow])
+	if tv.sortDesc {
+		c = -c
+	}
+	if c == 0 {
+		return cmp.Compare(a, b)
+	}
+	return c
+}
+
+// sortKey is a cell value parsed once for sorting.
+type sortKey struct {
+	text  string
+	num   float64
+	isNum bool
+}
+
+func newSortKey(v string) sortKey {
+	f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+	return sortKey{text: v, num: f, isNum: err == nil}
+}
+
+// compare orders two values, numerically when both are numbers.
+func (k sortKey) compare(o sortKey) int {
+	if k.isNum && o.isNum {
+		return cmp.Compare(k.num, o.num)
+	}
+	return strings.Compare(k.text, o.text)
+}
+
+// refresh redraws the table and the column stats. Stats are recomputed at
+// most every statsInterval, with a deferred update so the last lines count.
+func (tv *tableView) refresh() {
+	tv.table.Refresh()
+
+	tv.ui.mu.Lock()
+	if wait := statsInterval - time.Since(tv.statsTime); wait > 0 {
+		if !tv.statsPending {
+			tv.statsPending = true
+			time.AfterFunc(wait, func() {
+				fyne.Do(tv.refresh)
+			})
+		}
+		tv.ui.mu.Unlock()
+		return
+	}
+	tv.statsTime = time.Now()
+	tv.statsPending = false
+	text := tv.statsTextLocked()
+	tv.ui.mu.Unlock()
+	tv.statsLabel.SetText(text)
+}
+
+// statsTextLocked formats min/max/mean for each visible numeric column.
+// Must be called with ui.mu held.
+func (tv *tableView) statsTextLocked() string {
+	var parts []string
+	for _, col := range tv.visible {
+		st := tv.stats[tv.columns[col]]
+		if st == nil || st.count == 0 {
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%s: min %g  max %g  mean %.4g",
+			tv.titleLocked(col), st.mins[0].v, st.maxs[0].v, st.sum/float64(st.count)))
+	}
+	return strings.Join(parts, "   |   ")
+}
+
+func (tv *tableView) showColumnsDialog() {
+	tv.ui.mu.Lock()
+	columns := append([]string(nil), tv.columns...)
+	tv.ui.mu.Unlock()
+
+	if len(columns) == 0 {
+		dialog.ShowInformation("Columns", "No decoded columns yet.", tv.ui.window)
+		return
+	}
+
+	checks := container.NewVBox()
+	for _, name := range columns {
+		name := name
+		tv.ui.mu.Lock()
+		shown := !tv.hidden[name]
+		tv.ui.mu.Unlock()
+		check := widget.NewCheck(name, func(checked bool) {
+			tv.ui.mu.Lock()
+			if checked {
+				delete(tv.hidden, name)
+			} else {
+				tv.hidden[name] = true
+			}
+			tv.updateVisibleLocked()
+			tv.ui.mu.Unlock()
+			tv.table.Refresh()
+		})
+		check.SetChecked(shown)
+		checks.Add(check)
+	}
+	scroll := container.NewVScroll(checks)
+	scroll.SetMinSize(fyne.NewSize(250, 300))
+	dialog.ShowCustom("Show Columns", "Close", scroll, tv.ui.window)
+}
+
+// exportView returns the rows in display order with the visible column names
+// and their headers, so exports match what is on screen.
+func (tv *tableView) exportView() (lines []SerialLine, columns, header []string) {
+	tv.ui.mu.Lock()
+	defer tv.ui.mu.Unlock()
+	for _, id := range tv.order {
+		lines = append(lines, tv.rows[id-tv.firstRow].Line)
+	}
+	for _, col := range tv.visible {
+		columns = append(columns, tv.columns[col])
+		header = append(header, tv.titleLocked(col))
+	}
+	return lines, columns, header
+}
+
+// fieldValue returns the value of the named field, or "" if absent.
+func fieldValue(fields []Field, name string) string {
+	for _, f := range fields {
+		if f.Name == name {
+			return f.Value
+		}
+	}
+	return ""
+}
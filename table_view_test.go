@@ -0,0 +1,140 @@
+package main
+
+import (
+	"math/rand"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// newTestTableView returns a table without widgets over ui's lines.
+func newTestTableView(ui *AppUI) *tableView {
+	tv := &tableView{ui: ui, hidden: make(map[string]bool), sortCol: -1, stats: make(map[string]*columnStats)}
+	ui.tableView = tv
+	tv.rebuildLocked()
+	return tv
+}
+
+func TestColumnStatsSlidingWindow(t *testing.T) {
+	const window = 50
+	rng := rand.New(rand.NewSource(1))
+	var values []float64
+	var st columnStats
+	for row := range 1000 {
+		// Runs of rising and falling values exercise both deques.
+		v := float64(rng.Intn(20))
+		if row%200 < 100 {
+			v += float64(row)
+		} else {
+			v -= float64(row)
+		}
+		values = append(values, v)
+		st.add(row, v)
+		if len(values) > window {
+			oldest := row - window
+			st.remove(oldest, values[oldest])
+		}
+
+		live := values[max(0, len(values)-window):]
+		sum := 0.0
+		for _, x := range live {
+			sum += x
+		}
+		if st.count != len(live) || st.mins[0].v != slices.Min(live) || st.maxs[0].v != slices.Max(live) || st.sum != sum {
+			t.Fatalf("row %d: count %d min %g max %g sum %g, want %d %g %g %g",
+				row, st.count, st.mins[0].v, st.maxs[0].v, st.sum, len(live), slices.Min(live), slices.Max(live), sum)
+		}
+	}
+}
+
+func TestTableExportDecodesOnce(t *testing.T) {
+	ui := &AppUI{decoder: csvDecoder{}, encoding: EncodingLatin1}
+	ui.lines = []SerialLine{{Seq: 1, Data: "caf\xe9,1"}}
+	tv := newTestTableView(ui)
+	tv.addLocked(SerialLine{Seq: 2, Data: "th\xe9,2"})
+
+	lines, columns, header := tv.exportView()
+	path := filepath.Join(t.TempDir(), "out.jsonl")
+	_, err := Export(lines, ExportOptions{
+		FilePath:     path,
+		Format:       FormatJSONL,
+		Decoder:      ui.decoder,
+		Encoding:     ui.encoding,
+		Columns:      columns,
+		CustomHeader: header,
+		JSONL:        JSONLOptions{IncludeRaw: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"Field1":"café","Field2":"1","raw":"caf\\xE9,1"}` + "\n" +
+		`{"Field1":"thé","Field2":"2","raw":"th\\xE9,2"}` + "\n"
+	if got := string(data); got != want {
+		t.Errorf("exported\n%s\nwant\n%s", got, want)
+	}
+	if strings.Contains(string(data), "Ã") {
+		t.Error("text was decoded twice")
+	}
+}
+
+func TestTableOrderStaysSortedWhileTrimming(t *testing.T) {
+	for _, tc := range []struct {
+		col  int
+		desc bool
+	}{{-1, false}, {1, false}, {1, true}} {
+		ui := &AppUI{decoder: csvDecoder{}, encoding: EncodingUTF8}
+		tv := newTestTableView(ui)
+		rng := rand.New(rand.NewSource(1))
+		add := func(seq int) {
+			tv.addLocked(SerialLine{Seq: uint64(seq), Data: strconv.Itoa(seq) + "," + strconv.Itoa(rng.Intn(100))})
+		}
+		add(1)
+		tv.sortCol, tv.sortDesc = tc.col, tc.desc
+		tv.resortLocked()
+		for seq := 2; seq <= maxLines+500; seq++ {
+			add(seq)
+		}
+
+		if len(tv.rows) != maxLines || len(tv.order) != maxLines {
+			t.Fatalf("%+v: %d rows, %d in order, want %d", tc, len(tv.rows), len(tv.order), maxLines)
+		}
+		got := slices.Clone(tv.order)
+		tv.resortLocked()
+		if !slices.Equal(got, tv.order) {
+			t.Errorf("%+v: incremental order differs from a full sort", tc)
+		}
+	}
+}
+
+func TestTableFrozenWhilePaused(t *testing.T) {
+	ui := &AppUI{decoder: csvDecoder{}, encoding: EncodingUTF8}
+	ui.lines = []SerialLine{{Seq: 1, Data: "a,1"}, {Seq: 2, Data: "b,2"}}
+	tv := newTestTableView(ui)
+
+	// Pause, then lines arrive and the decoder is switched, which rebuilds.
+	ui.paused = true
+	ui.frozenLines = ui.lines
+	ui.lines = append(ui.lines, SerialLine{Seq: 3, Data: "c,3"}, SerialLine{Seq: 4, Data: "d,4"})
+	tv.rebuildLocked()
+	if len(tv.rows) != 2 {
+		t.Fatalf("paused table has %d rows, want the 2 shown when pausing", len(tv.rows))
+	}
+
+	ui.paused = false
+	ui.frozenLines = nil
+	tv.catchUpLocked()
+	var got []uint64
+	for _, id := range tv.order {
+		got = append(got, tv.rows[id-tv.firstRow].Line.Seq)
+	}
+	if want := []uint64{1, 2, 3, 4}; !slices.Equal(got, want) {
+		t.Errorf("after resuming, rows %v, want %v", got, want)
+	}
+}
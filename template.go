@@ -0,0 +1,291 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Column types a template can declare. Auto leaves the type to inference.
+const (
+	ColumnTypeAuto  = "auto"
+	ColumnTypeText  = "text"
+	ColumnTypeInt   = "integer"
+	ColumnTypeFloat = "float"
+)
+
+var templateColumnTypes = []string{ColumnTypeAuto, ColumnTypeText, ColumnTypeInt, ColumnTypeFloat}
+
+// TemplateColumn describes one column of a header template.
+type TemplateColumn struct {
+	Name string `json:"name"`
+	Unit string `json:"unit,omitempty"`
+	Type string `json:"type,omitempty"` // one of templateColumnTypes; auto if empty
+}
+
+// HeaderTemplate is a named, reusable header for exports and the table view.
+type HeaderTemplate struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description,omitempty"`
+	Columns     []TemplateColumn `json:"columns"`
+}
+
+// UnmarshalJSON also accepts the plain comma-separated strings that older
+// versions saved, so existing settings and profiles keep working.
+func (t *HeaderTemplate) UnmarshalJSON(data []byte) error {
+	var legacy string
+	if err := json.Unmarshal(data, &legacy); err == nil {
+		*t = templateFromHeader(legacy, legacy)
+		return nil
+	}
+	type plain HeaderTemplate // without this method, to avoid recursion
+	return json.Unmarshal(data, (*plain)(t))
+}
+
+// validate checks that a template, e.g. one read from a shared file, has a
+// name, columns and only known column types.
+func (t HeaderTemplate) validate() error {
+	if strings.TrimSpace(t.Name) == "" {
+		return fmt.Errorf("template has no name")
+	}
+	if len(t.Columns) == 0 {
+		return fmt.Errorf("template %q has no columns", t.Name)
+	}
+	for i, c := range t.Columns {
+		if c.Type != "" && !slices.Contains(templateColumnTypes, c.Type) {
+			return fmt.Errorf("template %q: column %d (%s) has unknown type %q", t.Name, i+1, c.Name, c.Type)
+		}
+	}
+	return nil
+}
+
+// templateFromHeader builds a template from a comma-separated header line.
+func templateFromHeader(name, header string) HeaderTemplate {
+	t := HeaderTemplate{Name: name}
+	for _, col := range strings.Split(header, ",") {
+		t.Columns = append(t.Columns, TemplateColumn{Name: strings.TrimSpace(col)})
+	}
+	return t
+}
+
+// title returns a column's header text, with its unit in brackets.
+func (c TemplateColumn) title() string {
+	if c.Unit == "" {
+		return c.Name
+	}
+	return fmt.Sprintf("%s [%s]", c.Name, c.Unit)
+}
+
+// Header returns the template's header row.
+func (t HeaderTemplate) Header() []string {
+	header := make([]string, len(t.Columns))
+	for i, c := range t.Columns {
+		header[i] = c.title()
+	}
+	return header
+}
+
+// Types returns the declared type of each column.
+func (t HeaderTemplate) Types() []string {
+	types := make([]string, len(t.Columns))
+	for i, c := range t.Columns {
+		types[i] = c.Type
+	}
+	return types
+}
+
+// columnTypeOf converts a template column type to an export column type,
+// reporting false for auto.
+func columnTypeOf(typ string) (ColumnType, bool) {
+	switch typ {
+	case ColumnTypeText:
+		return ColumnString, true
+	case ColumnTypeInt:
+		return ColumnInt, true
+	case ColumnTypeFloat:
+		return ColumnFloat, true
+	}
+	return ColumnString, false
+}
+
+// templateNames returns the names of the given templates.
+func templateNames(templates []HeaderTemplate) []string {
+	names := make([]string, len(templates))
+	for i, t := range templates {
+		names[i] = t.Name
+	}
+	return names
+}
+
+// templateByName finds a template by name.
+func templateByName(templates []HeaderTemplate, name string) (HeaderTemplate, bool) {
+	for _, t := range templates {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return HeaderTemplate{}, false
+}
+
+// uniqueTemplateName returns name, or name with a number appended if a
+// template by that name already exists.
+func uniqueTemplateName(templates []HeaderTemplate, name string) string {
+	candidate := name
+	for n := 2; ; n++ {
+		if _, exists := templateByName(templates, candidate); !exists {
+			return candidate
+		}
+		candidate = fmt.Sprintf("%s (%d)", name, n)
+	}
+}
+
+// templateFile is the on-disk layout of exported templates.
+type templateFile struct {
+	Templates []HeaderTemplate `json:"templates"`
+}
+
+// ExportTemplates writes templates to a JSON file for sharing.
+func ExportTemplates(path string, templates []HeaderTemplate) error {
+	data, err := json.MarshalIndent(templateFile{Templates: templates}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal templates: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write templates: %w", err)
+	}
+	return nil
+}
+
+// ImportTemplates reads templates written by ExportTemplates.
+func ImportTemplates(path string) ([]HeaderTemplate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read templates: %w", err)
+	}
+	var f templateFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse templates: %w", err)
+	}
+	if len(f.Templates) == 0 {
+		return nil, fmt.Errorf("no templates in %s", path)
+	}
+	for _, t := range f.Templates {
+		if err := t.validate(); err != nil {
+			return nil, err
+		}
+	}
+	return f.Templates, nil
+}
+
+// mergeTemplates adds incoming templates to existing ones, replacing any
+// with the same name.
+func mergeTemplates(existing, incoming []HeaderTemplate) []HeaderTemplate {
+	merged := append([]HeaderTemplate(nil), existing...)
+	for _, t := range incoming {
+		replaced := false
+		for i := range merged {
+			if merged[i].Name == t.Name {
+				merged[i] = t
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			merged = append(merged, t)
+		}
+	}
+	return merged
+}
+
+// detectTemplate proposes a template from the lines the device printed. A
+// first line whose values are all non-numeric is taken as a header row. Each
+// column's type is the narrowest that fits every value in the other lines:
+// integer, then float, then text.
+func detectTemplate(lines []SerialLine, decoder Decoder, encoding string) (HeaderTemplate, error) {
+	var rows [][]Field
+	for _, line := range lines {
+		data := line.Data
+		if !isBinaryDecoder(decoder) {
+			data = decodeText(data, encoding)
+		}
+		fields, err := decoder.Decode(data)
+		if err != nil || len(fields) == 0 {
+			continue
+		}
+		rows = append(rows, fields)
+	}
+	if len(rows) == 0 {
+		return HeaderTemplate{}, fmt.Errorf("no decodable lines received yet")
+	}
+
+	first, samples := rows[0], rows
+	isHeader := true
+	for _, f := range first {
+		v := strings.TrimSpace(f.Value)
+		if v == "" || isNumber(v) {
+			isHeader = false
+			break
+		}
+	}
+	// Only positional decoders print their header as data.
+	if _, positional := decoder.(csvDecoder); !positional {
+		isHeader = false
+	}
+	if isHeader {
+		samples = rows[1:]
+	}
+
+	t := HeaderTemplate{Name: "Detected", Description: "Detected from device output"}
+	for i, f := range first {
+		col := TemplateColumn{Name: f.Name, Type: ColumnTypeAuto}
+		if isHeader {
+			col.Name = strings.TrimSpace(f.Value)
+		}
+		var values []string
+		for _, row := range samples {
+			if i < len(row) {
+				values = append(values, row[i].Value)
+			}
+		}
+		if len(values) > 0 {
+			col.Type = guessColumnType(values)
+		}
+		t.Columns = append(t.Columns, col)
+	}
+	return t, nil
+}
+
+func isNumber(v string) bool {
+	_, err := strconv.ParseFloat(v, 64)
+	return err == nil
+}
+
+// guessColumnType picks the narrowest template type that fits every
+// non-empty value, or Auto if all are empty.
+func guessColumnType(values []string) string {
+	typ := ""
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		switch {
+		case v == "":
+		case typ != ColumnTypeFloat && typ != ColumnTypeText && isInteger(v):
+			typ = ColumnTypeInt
+		case typ != ColumnTypeText && isNumber(v):
+			typ = ColumnTypeFloat
+		default:
+			return ColumnTypeText
+		}
+	}
+	if typ == "" {
+		return ColumnTypeAuto
+	}
+	return typ
+}
+
+func isInteger(v string) bool {
+	_, err := strconv.ParseInt(v, 10, 64)
+	return err == nil
+}
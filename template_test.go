@@ -0,0 +1,33 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDetectTemplateChecksEveryLine(t *testing.T) {
+	for _, tc := range []struct {
+		lines []string
+		want  []string
+	}{
+		{[]string{"1,0", "2,0.5", "3,ERR"}, []string{ColumnTypeInt, ColumnTypeText}},
+		{[]string{"1,0", "2,0.5", "3,7"}, []string{ColumnTypeInt, ColumnTypeFloat}},
+		{[]string{"id,temp", "1,", "2,"}, []string{ColumnTypeInt, ColumnTypeAuto}},
+	} {
+		var lines []SerialLine
+		for _, data := range tc.lines {
+			lines = append(lines, SerialLine{Data: data})
+		}
+		tmpl, err := detectTemplate(lines, csvDecoder{}, EncodingUTF8)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, c := range tmpl.Columns {
+			got = append(got, c.Type)
+		}
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("%q: types %q, want %q", tc.lines, got, tc.want)
+		}
+	}
+}
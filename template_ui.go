@@ -0,0 +1,265 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// templatesChanged saves the templates and refreshes the views that list them.
+func (ui *AppUI) templatesChanged() {
+	ui.saveSettings()
+	ui.tableView.refreshTemplates()
+	ui.tableView.applyHeaderTemplate()
+}
+
+// showTemplateEditor opens the header template editor. Edits apply to the
+// saved templates as they are made; onChanged, if set, runs when it closes.
+func (ui *AppUI) showTemplateEditor(onChanged func()) {
+	selected := -1
+
+	nameEntry := widget.NewEntry()
+	descEntry := widget.NewEntry()
+	descEntry.SetPlaceHolder("Description")
+	columnsBox := container.NewVBox()
+	editor := container.NewVBox()
+
+	list := widget.NewList(
+		func() int { return len(ui.savedTemplates) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			if id < len(ui.savedTemplates) {
+				obj.(*widget.Label).SetText(ui.savedTemplates[id].Name)
+			}
+		},
+	)
+
+	current := func() *HeaderTemplate {
+		if selected < 0 || selected >= len(ui.savedTemplates) {
+			return nil
+		}
+		return &ui.savedTemplates[selected]
+	}
+
+	var rebuildColumns func()
+	rebuildColumns = func() {
+		columnsBox.RemoveAll()
+		t := current()
+		if t == nil {
+			return
+		}
+		for i := range t.Columns {
+			i := i
+			col := &t.Columns[i]
+			name := widget.NewEntry()
+			name.SetText(col.Name)
+			name.SetPlaceHolder("Name")
+			name.OnChanged = func(s string) { col.Name = s }
+			unit := widget.NewEntry()
+			unit.SetText(col.Unit)
+			unit.SetPlaceHolder("Unit")
+			unit.OnChanged = func(s string) { col.Unit = s }
+			typ := widget.NewSelect(templateColumnTypes, func(s string) { col.Type = s })
+			if col.Type == "" {
+				typ.SetSelected(ColumnTypeAuto)
+			} else {
+				typ.SetSelected(col.Type)
+			}
+			up := widget.NewButtonWithIcon("", theme.MoveUpIcon(), func() {
+				if i > 0 {
+					t.Columns[i-1], t.Columns[i] = t.Columns[i], t.Columns[i-1]
+					rebuildColumns()
+				}
+			})
+			down := widget.NewButtonWithIcon("", theme.MoveDownIcon(), func() {
+				if i < len(t.Columns)-1 {
+					t.Columns[i+1], t.Columns[i] = t.Columns[i], t.Columns[i+1]
+					rebuildColumns()
+				}
+			})
+			remove := widget.NewButtonWithIcon("", theme.DeleteIcon(), func() {
+				t.Columns = slices.Delete(t.Columns, i, i+1)
+				rebuildColumns()
+			})
+			columnsBox.Add(container.NewBorder(nil, nil, nil,
+				container.NewHBox(typ, up, down, remove),
+				container.NewGridWithColumns(2, name, unit)))
+		}
+	}
+
+	showSelected := func() {
+		t := current()
+		if t == nil {
+			editor.Hide()
+			return
+		}
+		nameEntry.SetText(t.Name)
+		descEntry.SetText(t.Description)
+		rebuildColumns()
+		editor.Show()
+	}
+	list.OnSelected = func(id widget.ListItemID) {
+		selected = id
+		showSelected()
+	}
+	selectTemplate := func(id int) {
+		list.Refresh()
+		list.Select(id)
+	}
+
+	// Renames take effect once the name is non-empty and unique.
+	nameEntry.OnChanged = func(s string) {
+		t := current()
+		s = strings.TrimSpace(s)
+		if t == nil || s == "" || s == t.Name {
+			return
+		}
+		if _, exists := templateByName(ui.savedTemplates, s); exists {
+			return
+		}
+		if ui.headerTemplate == t.Name {
+			ui.headerTemplate = s
+		}
+		t.Name = s
+		list.RefreshItem(selected)
+	}
+	descEntry.OnChanged = func(s string) {
+		if t := current(); t != nil {
+			t.Description = s
+		}
+	}
+
+	addColumnBtn := widget.NewButtonWithIcon("Add Column", theme.ContentAddIcon(), func() {
+		if t := current(); t != nil {
+			t.Columns = append(t.Columns, TemplateColumn{Name: fmt.Sprintf("Column%d", len(t.Columns)+1), Type: ColumnTypeAuto})
+			rebuildColumns()
+		}
+	})
+
+	newBtn := widget.NewButton("New", func() {
+		ui.savedTemplates = append(ui.savedTemplates, HeaderTemplate{Name: uniqueTemplateName(ui.savedTemplates, "New Template")})
+		selectTemplate(len(ui.savedTemplates) - 1)
+	})
+	duplicateBtn := widget.NewButton("Duplicate", func() {
+		t := current()
+		if t == nil {
+			return
+		}
+		dup := *t
+		dup.Name = uniqueTemplateName(ui.savedTemplates, t.Name+" copy")
+		dup.Columns = slices.Clone(t.Columns)
+		ui.savedTemplates = slices.Insert(ui.savedTemplates, selected+1, dup)
+		selectTemplate(selected + 1)
+	})
+	deleteBtn := widget.NewButton("Delete", func() {
+		if current() == nil {
+			return
+		}
+		ui.savedTemplates = slices.Delete(ui.savedTemplates, selected, selected+1)
+		selected = -1
+		list.UnselectAll()
+		list.Refresh()
+		showSelected()
+	})
+	moveUpBtn := widget.NewButtonWithIcon("", theme.MoveUpIcon(), func() {
+		if current() != nil && selected > 0 {
+			ui.savedTemplates[selected-1], ui.savedTemplates[selected] = ui.savedTemplates[selected], ui.savedTemplates[selected-1]
+			selectTemplate(selected - 1)
+		}
+	})
+	moveDownBtn := widget.NewButtonWithIcon("", theme.MoveDownIcon(), func() {
+		if current() != nil && selected < len(ui.savedTemplates)-1 {
+			ui.savedTemplates[selected+1], ui.savedTemplates[selected] = ui.savedTemplates[selected], ui.savedTemplates[selected+1]
+			selectTemplate(selected + 1)
+		}
+	})
+
+	detectBtn := widget.NewButton("Auto-detect", func() {
+		ui.mu.Lock()
+		lines := slices.Clone(ui.lines)
+		decoder := ui.decoder
+		encoding := ui.encoding
+		ui.mu.Unlock()
+		t, err := detectTemplate(lines, decoder, encoding)
+		if err != nil {
+			dialog.ShowError(err, ui.window)
+			return
+		}
+		t.Name = uniqueTemplateName(ui.savedTemplates, t.Name)
+		ui.savedTemplates = append(ui.savedTemplates, t)
+		selectTemplate(len(ui.savedTemplates) - 1)
+	})
+
+	importBtn := widget.NewButton("Import...", func() {
+		fd := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil || reader == nil {
+				return
+			}
+			reader.Close()
+			templates, err := ImportTemplates(localPath(reader.URI()))
+			if err != nil {
+				dialog.ShowError(err, ui.window)
+				return
+			}
+			ui.savedTemplates = mergeTemplates(ui.savedTemplates, templates)
+			list.Refresh()
+			showSelected()
+			dialog.ShowInformation("Import Templates", fmt.Sprintf("Imported %d templates.", len(templates)), ui.window)
+		}, ui.window)
+		fd.SetFilter(storage.NewExtensionFileFilter([]string{".json"}))
+		fd.Show()
+	})
+	exportBtn := widget.NewButton("Export...", func() {
+		if len(ui.savedTemplates) == 0 {
+			return
+		}
+		templates := slices.Clone(ui.savedTemplates)
+		fd := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+			if err != nil || writer == nil {
+				return
+			}
+			writer.Close()
+			if err := ExportTemplates(localPath(writer.URI()), templates); err != nil {
+				dialog.ShowError(err, ui.window)
+			}
+		}, ui.window)
+		fd.SetFileName("templates.json")
+		fd.SetFilter(storage.NewExtensionFileFilter([]string{".json"}))
+		fd.Show()
+	})
+
+	editor.Objects = []fyne.CanvasObject{
+		widget.NewForm(
+			widget.NewFormItem("Name", nameEntry),
+			widget.NewFormItem("Description", descEntry),
+		),
+		widget.NewLabelWithStyle("Columns", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		columnsBox,
+		addColumnBtn,
+	}
+	editor.Hide()
+
+	listButtons := container.NewGridWithColumns(2,
+		newBtn, duplicateBtn, deleteBtn, container.NewGridWithColumns(2, moveUpBtn, moveDownBtn),
+		detectBtn, widget.NewLabel(""), importBtn, exportBtn)
+	left := container.NewBorder(nil, listButtons, nil, nil, list)
+	split := container.NewHSplit(left, container.NewVScroll(editor))
+	split.Offset = 0.3
+
+	d := dialog.NewCustom("Header Templates", "Close", split, ui.window)
+	d.SetOnClosed(func() {
+		ui.templatesChanged()
+		if onChanged != nil {
+			onChanged()
+		}
+	})
+	d.Resize(fyne.NewSize(820, 480))
+	d.Show()
+}
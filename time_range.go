@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Time range modes offered in the export dialog.
+const (
+	RangeAll       = "All"
+	RangeAbsolute  = "Between"
+	RangeLast      = "Last"
+	RangeFirst     = "First after connect"
+	RangeSelection = "Selected lines"
+)
+
+var timeRangeModes = []string{RangeAll, RangeAbsolute, RangeLast, RangeFirst, RangeSelection}
+
+// Layouts accepted for range boundaries. Date-less layouts are resolved
+// against the capture's dates by resolveTimeRange.
+var (
+	dateTimeLayouts = []string{
+		time.RFC3339Nano,
+		"2006-01-02 15:04:05.000",
+		"2006-01-02 15:04:05",
+		"2006-01-02 15:04",
+		"2006-01-02T15:04:05.000",
+		"2006-01-02T15:04:05",
+	}
+	timeOfDayLayouts = []string{
+		"15:04:05.000",
+		"15:04:05",
+		"15:04",
+	}
+)
+
+// parseBoundary parses a date-time or time of day in local time. It reports
+// whether the text carried a date.
+func parseBoundary(text string) (t time.Time, hasDate bool, err error) {
+	for _, layout := range dateTimeLayouts {
+		if t, err := time.ParseInLocation(layout, text, time.Local); err == nil {
+			return t, true, nil
+		}
+	}
+	for _, layout := range timeOfDayLayouts {
+		if t, err := time.ParseInLocation(layout, text, time.Local); err == nil {
+			return t, false, nil
+		}
+	}
+	return time.Time{}, false, fmt.Errorf("invalid time %q (use HH:MM:SS[.mmm] or YYYY-MM-DD HH:MM:SS[.mmm])", text)
+}
+
+// onDate places a time of day on the date of ref.
+func onDate(tod, ref time.Time) time.Time {
+	return time.Date(ref.Year(), ref.Month(), ref.Day(),
+		tod.Hour(), tod.Minute(), tod.Second(), tod.Nanosecond(), time.Local)
+}
+
+// onCaptureDate places a time of day on the date of the capture's first
+// line, or on the next day if it falls before that line, as in a capture
+// that runs past midnight.
+func onCaptureDate(tod, first time.Time) time.Time {
+	t := onDate(tod, first)
+	if t.Before(first.Truncate(time.Minute)) {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t
+}
+
+// resolveTimeRange parses the start and end of an absolute range. Times of
+// day are placed on the capture's dates by onCaptureDate, and an end that
+// falls before the start moves to the next day so overnight captures can be
+// filtered. An empty start or end leaves that side open.
+func resolveTimeRange(startText, endText string, first time.Time) (start, end time.Time, err error) {
+	startText = strings.TrimSpace(startText)
+	endText = strings.TrimSpace(endText)
+
+	if startText != "" {
+		t, hasDate, err := parseBoundary(startText)
+		if err != nil {
+			return start, end, fmt.Errorf("start: %w", err)
+		}
+		if !hasDate {
+			t = onCaptureDate(t, first)
+		}
+		start = t
+	}
+
+	if endText == "" {
+		return start, time.Now(), nil
+	}
+	t, hasDate, err := parseBoundary(endText)
+	if err != nil {
+		return start, end, fmt.Errorf("end: %w", err)
+	}
+	if !hasDate {
+		if start.IsZero() {
+			t = onCaptureDate(t, first)
+		} else if t = onDate(t, start); t.Before(start) {
+			t = t.AddDate(0, 0, 1)
+		}
+	}
+	if !start.IsZero() && t.Before(start) {
+		return start, end, fmt.Errorf("end %s is before start %s", endText, startText)
+	}
+	return start, t, nil
+}
+
+// lastSpan returns the range covering span up to the newest line, so a
+// stopped or reopened capture exports its own last minutes. It counts back
+// from now when there are no lines.
+func lastSpan(span time.Duration, last time.Time) (start, end time.Time) {
+	if last.IsZero() {
+		last = time.Now()
+	}
+	return last.Add(-span), last
+}
+
+// parseRelativeDuration parses spans such as "5m", "30 s", "1.5 minutes" or a
+// bare number of seconds.
+func parseRelativeDuration(text string) (time.Duration, error) {
+	text = strings.ToLower(strings.TrimSpace(text))
+	if d, err := time.ParseDuration(strings.ReplaceAll(text, " ", "")); err == nil && d > 0 {
+		return d, nil
+	}
+
+	num, unit := text, ""
+	if idx := strings.IndexFunc(text, func(r rune) bool { return r == ' ' || (r >= 'a' && r <= 'z') }); idx >= 0 {
+		num, unit = strings.TrimSpace(text[:idx]), strings.TrimSpace(text[idx:])
+	}
+	n, err := strconv.ParseFloat(num, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid duration %q (e.g. 30s, 5m, 2 hours)", text)
+	}
+
+	var scale time.Duration
+	switch unit {
+	case "", "s", "sec", "secs", "second", "seconds":
+		scale = time.Second
+	case "ms", "msec", "millisecond", "milliseconds":
+		scale = time.Millisecond
+	case "m", "min", "mins", "minute", "minutes":
+		scale = time.Minute
+	case "h", "hr", "hrs", "hour", "hours":
+		scale = time.Hour
+	default:
+		return 0, fmt.Errorf("unknown unit %q in duration", unit)
+	}
+	return time.Duration(n * float64(scale)), nil
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestResolveTimeRangeOvernight(t *testing.T) {
+	first := time.Date(2026, 3, 1, 23, 30, 0, 0, time.Local)
+	nextDay := time.Date(2026, 3, 2, 0, 15, 0, 0, time.Local)
+	for _, tc := range []struct {
+		start, end         string
+		wantStart, wantEnd time.Time
+	}{
+		// A start before the first line's time of day is after midnight.
+		{"00:15", "00:30", nextDay, nextDay.Add(15 * time.Minute)},
+		{"23:45", "00:30", first.Add(15 * time.Minute), nextDay.Add(15 * time.Minute)},
+		{"23:30", "23:40", first, first.Add(10 * time.Minute)},
+		{"", "00:15", time.Time{}, nextDay},
+	} {
+		start, end, err := resolveTimeRange(tc.start, tc.end, first.Add(500*time.Millisecond))
+		if err != nil {
+			t.Errorf("%s-%s: %v", tc.start, tc.end, err)
+			continue
+		}
+		if !start.Equal(tc.wantStart) || !end.Equal(tc.wantEnd) {
+			t.Errorf("%s-%s: got %s to %s, want %s to %s", tc.start, tc.end, start, end, tc.wantStart, tc.wantEnd)
+		}
+	}
+}
+
+func TestLastSpanCountsFromNewestLine(t *testing.T) {
+	// A capture from yesterday, exported after disconnecting.
+	last := time.Now().Add(-24 * time.Hour)
+	start, end := lastSpan(5*time.Minute, last)
+	if !end.Equal(last) || !start.Equal(last.Add(-5*time.Minute)) {
+		t.Errorf("got %s to %s, want the 5 minutes before %s", start, end, last)
+	}
+
+	before := time.Now()
+	if _, end := lastSpan(time.Minute, time.Time{}); end.Before(before) {
+		t.Errorf("without lines, range ends at %s, before now", end)
+	}
+}
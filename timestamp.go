@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// Timestamp modes for the output display.
+const (
+	DisplayWallClock      = "Wall clock"
+	DisplayWallClockMicro = "Wall clock (µs)"
+	DisplaySinceConnect   = "Since connect"
+	DisplayDelta          = "Delta"
+)
+
+var displayTimestampModes = []string{DisplayWallClock, DisplayWallClockMicro, DisplaySinceConnect, DisplayDelta}
+
+// Timestamp output formats for exports.
+const (
+	TimestampLocal        = "Local"
+	TimestampUTC          = "UTC"
+	TimestampISO8601      = "ISO 8601"
+	TimestampUnix         = "Unix epoch"
+	TimestampElapsed      = "Elapsed seconds"
+	TimestampSinceConnect = "Seconds since connect"
+	TimestampDelta        = "Delta seconds"
+)
+
+var timestampFormats = []string{
+	TimestampLocal, TimestampUTC, TimestampISO8601, TimestampUnix,
+	TimestampElapsed, TimestampSinceConnect, TimestampDelta,
+}
+
+// connectOrigin returns the start of the connection a line received at t
+// belongs to: the latest of starts, oldest first, that isn't after t. It is
+// zero if t is before them all.
+func connectOrigin(starts []time.Time, t time.Time) time.Time {
+	i, _ := slices.BinarySearchFunc(starts, t, func(s, t time.Time) int {
+		if s.After(t) {
+			return 1
+		}
+		return -1
+	})
+	if i == 0 {
+		return time.Time{}
+	}
+	return starts[i-1]
+}
+
+// formatDisplayTimestamp renders the prefix shown before a line. origin is
+// the start of the line's connection and prev the previous line's timestamp,
+// zero for the first line.
+func formatDisplayTimestamp(t time.Time, mode string, origin, prev time.Time) string {
+	switch mode {
+	case DisplayWallClockMicro:
+		return t.Format("15:04:05.000000")
+	case DisplaySinceConnect:
+		if origin.IsZero() {
+			origin = t
+		}
+		return fmt.Sprintf("+%.6f", t.Sub(origin).Seconds())
+	case DisplayDelta:
+		if prev.IsZero() {
+			prev = t
+		}
+		return fmt.Sprintf("Δ%.6f", t.Sub(prev).Seconds())
+	default:
+		return t.Format("15:04:05.000")
+	}
+}
+
+// formatExportTimestamp renders a line's timestamp in the chosen output
+// format. Elapsed and since-connect times are measured from origin, and delta
+// times from prev, the previous exported line.
+func formatExportTimestamp(t time.Time, format string, origin, prev time.Time) string {
+	switch format {
+	case TimestampUTC:
+		return t.UTC().Format("2006-01-02 15:04:05.000")
+	case TimestampISO8601:
+		return t.Format("2006-01-02T15:04:05.000Z07:00")
+	case TimestampUnix:
+		return strconv.FormatFloat(float64(t.UnixMicro())/1e6, 'f', 6, 64)
+	case TimestampElapsed, TimestampSinceConnect:
+		return strconv.FormatFloat(t.Sub(origin).Seconds(), 'f', 6, 64)
+	case TimestampDelta:
+		if prev.IsZero() {
+			prev = t
+		}
+		return strconv.FormatFloat(t.Sub(prev).Seconds(), 'f', 6, 64)
+	default:
+		return t.Format("2006-01-02 15:04:05.000")
+	}
+}
@@ -2,6 +2,12 @@ package main
 
 import (
 	"fmt"
+	"image/color"
+	"io"
+	"log"
+	"maps"
+	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -9,9 +15,13 @@ import (
 	"time"
 
 	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/driver"
+	"fyne.io/fyne/v2/driver/desktop"
 	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 )
 
@@ -23,24 +33,92 @@ type AppUI struct {
 	serial *SerialManager
 
 	// Widgets
-	portSelect    *widget.Select
-	baudSelect    *widget.Select
-	connectBtn    *widget.Button
-	clearBtn      *widget.Button
-	exportBtn     *widget.Button
-	autoscrollChk *widget.Check
-	timestampChk  *widget.Check
-	output        *widget.List
-	refreshBtn    *widget.Button
+	portSelect     *widget.Select
+	baudSelect     *widget.Select
+	dataBitsSel    *widget.Select
+	paritySel      *widget.Select
+	stopBitsSel    *widget.Select
+	encodingSel    *widget.Select
+	profileSelect  *widget.Select
+	profilesBtn    *widget.Button
+	connectBtn     *widget.Button
+	clearBtn       *widget.Button
+	exportBtn      *widget.Button
+	openSessionBtn *widget.Button
+	saveSessionBtn *widget.Button
+	sendFileBtn    *widget.Button
+	transferBtn    *widget.Button
+	modbusBtn      *widget.Button
+	latencyBtn     *widget.Button
+	apiBtn         *widget.Button
+	bridgeBtn      *widget.Button
+	mqttBtn        *widget.Button
+	sinksBtn       *widget.Button
+	simulatorBtn   *widget.Button
+	statsBtn       *widget.Button
+	pauseBtn       *widget.Button
+	pausedLabel    *widget.Label
+	copyBtn        *widget.Button
+	bookmarkBtn    *widget.Button
+	noteBtn        *widget.Button
+	prevMarkBtn    *widget.Button
+	nextMarkBtn    *widget.Button
+	autoscrollChk  *widget.Check
+	timestampChk   *widget.Check
+	timestampSel   *widget.Select
+	decoderSelect  *widget.Select
+	tableChk       *widget.Check
+	invisiblesSel  *widget.Select
+	keepCRChk      *widget.Check
+	tableView      *tableView
+	output         *widget.List
+	refreshBtn     *widget.Button
+	healthBanner   *fyne.Container
+	healthLabel    *widget.Label
 
 	// State
 	mu             sync.Mutex
 	lines          []SerialLine
 	displayLines   []string
+	paused         bool         // output view frozen while capture continues
+	frozenLines    []SerialLine // lines shown while paused
+	frozenDisplay  []string
+	pausedCount    int         // lines received while paused
+	refreshPending atomic.Bool // an output redraw is queued
 	autoscroll     bool
 	showTimestamp  bool
+	timestampMode  string  // one of displayTimestampModes
+	decoder        Decoder // per-session frame decoder for display and export
+	encoding       string  // text encoding of the device's output, one of encodingNames
+	tableMode      bool    // show decoded columns instead of raw lines
+	invisibles     string  // how control characters are shown, one of invisiblesModes
 	connected      atomic.Bool
-	savedTemplates []string // user-saved CSV header templates
+	settings       Settings         // persisted state, updated by saveSettings
+	lineEnding     string           // default line ending for Send File
+	headerTemplate string           // name of the header template chosen by the active profile
+	savedTemplates []HeaderTemplate // user-saved header templates
+	modbusPolls    []ModbusPoll
+	modbus         *modbusView // open Modbus window, if any
+	connectedAt    time.Time   // when the current or last connection opened
+	connects       []time.Time // when each connection this session opened, oldest first
+	selAnchor      uint64      // Seq of the last plainly clicked line; 0 if none
+	selStart       uint64      // selected Seq range, for copying and export
+	selEnd         uint64
+	selCursor      uint64            // Seq of the last clicked line
+	nextSeq        uint64            // Seq for the next appended line
+	bookmarks      map[uint64]string // notes by line Seq; "" for a bookmark without a note
+	lineHub        lineHub           // live lines for the API and other consumers
+	api            *APIServer        // running local API server, if enabled
+	bridge         *Bridge           // running serial-to-TCP bridge, if started
+	mqttMu         sync.Mutex        // guards mqtt, which is started off the UI thread
+	mqtt           *MQTTPublisher    // running MQTT publisher, if enabled
+	sinks          *SinkPipeline     // output sinks fed with every received line
+	simulator      *Simulator        // running fake device, if started
+	stats          *LineStats        // link health figures for lines read from the port
+	healthAckMu    sync.Mutex        // guards healthAck and healthSeen, read by the health watcher
+	healthAck      healthAck         // counts when the data loss banner was dismissed
+	healthSeen     healthAck         // counts at the last health check
+	settingsErr    error             // why settings couldn't be loaded; they aren't saved while set
 }
 
 var standardBaudRates = []string{
@@ -50,17 +128,175 @@ var standardBaudRates = []string{
 }
 
 func NewAppUI(window fyne.Window, serial *SerialManager) *AppUI {
-	templates, _ := LoadTemplates()
+	settings, settingsErr := LoadSettings()
 	ui := &AppUI{
 		window:         window,
 		serial:         serial,
+		settings:       settings,
+		settingsErr:    settingsErr,
 		autoscroll:     true,
-		savedTemplates: templates,
+		timestampMode:  DisplayWallClock,
+		decoder:        csvDecoder{},
+		encoding:       EncodingUTF8,
+		invisibles:     InvisiblesHidden,
+		lineEnding:     settings.Connection.LineEnding,
+		savedTemplates: settings.Templates,
+		modbusPolls:    settings.Modbus,
+		bookmarks:      make(map[uint64]string),
 	}
+	ui.stats = NewLineStats()
+	ui.sinks = NewSinkPipeline(func() Decoder {
+		ui.mu.Lock()
+		defer ui.mu.Unlock()
+		return ui.decoder
+	})
 	ui.build()
+	ui.applySettings()
+	if settingsErr != nil {
+		log.Printf("failed to load settings: %v", settingsErr)
+		dialog.ShowError(fmt.Errorf("%w\n\nSettings won't be saved this session, so the file is left as it is.", settingsErr), window)
+	}
+	go ui.watchHealth()
+	go ui.watchPorts()
+	window.SetCloseIntercept(func() {
+		ui.saveSettings()
+		ui.stopAPI()
+		ui.stopBridge()
+		ui.stopMQTT()
+		ui.sinks.Close()
+		ui.stopSimulator()
+		window.Close()
+	})
 	return ui
 }
 
+// applySettings restores the window and controls from the loaded settings.
+func (ui *AppUI) applySettings() {
+	s := ui.settings
+	if s.Window.Width > 0 && s.Window.Height > 0 {
+		ui.window.Resize(fyne.NewSize(s.Window.Width, s.Window.Height))
+	}
+	if pos := s.Window.Position; pos != nil {
+		// The native window only exists once the app is running.
+		fyne.CurrentApp().Lifecycle().SetOnStarted(func() {
+			if hwnd := windowHandle(ui.window); hwnd != 0 {
+				setNativeWindowPosition(hwnd, *pos)
+			}
+		})
+	}
+	if s.Window.FullScreen {
+		ui.window.SetFullScreen(true)
+	}
+	if s.Connection.Port != "" && slices.Contains(ui.portSelect.Options, s.Connection.Port) {
+		ui.portSelect.SetSelected(s.Connection.Port)
+	}
+	if s.Connection.BaudRate > 0 {
+		ui.baudSelect.SetSelected(strconv.Itoa(s.Connection.BaudRate))
+	}
+	ui.setFraming(s.Connection.Framing)
+	if slices.Contains(encodingNames, s.Connection.Encoding) {
+		ui.encodingSel.SetSelected(s.Connection.Encoding)
+	}
+	ui.serial.SetBackpressure(s.Connection.Backpressure)
+	ui.autoscrollChk.SetChecked(s.Display.Autoscroll)
+	ui.timestampChk.SetChecked(s.Display.Timestamps)
+	if slices.Contains(displayTimestampModes, s.Display.TimestampMode) {
+		ui.timestampSel.SetSelected(s.Display.TimestampMode)
+	}
+	if slices.Contains(decoderNames(), s.Display.Decoder) {
+		ui.decoderSelect.SetSelected(s.Display.Decoder)
+	}
+	ui.tableChk.SetChecked(s.Display.TableMode)
+	if slices.Contains(invisiblesModes, s.Display.Invisibles) {
+		ui.invisiblesSel.SetSelected(s.Display.Invisibles)
+	}
+	ui.keepCRChk.SetChecked(s.Connection.KeepCR)
+	ui.refreshProfiles()
+	if s.API.Enabled {
+		if err := ui.startAPI(); err != nil {
+			log.Printf("failed to start API: %v", err)
+		}
+	}
+	ui.applySinks()
+	if s.Simulator.AutoStart && simulatorSupported {
+		if err := ui.startSimulator(s.Simulator); err != nil {
+			log.Printf("failed to start simulator: %v", err)
+		}
+	}
+	if s.MQTT.Enabled {
+		go func() {
+			if err := ui.startMQTT(s.MQTT); err != nil {
+				log.Printf("failed to start MQTT: %v", err)
+			}
+		}()
+	}
+	if !ui.autoApplyProfile() && slices.ContainsFunc(s.Profiles, func(p Profile) bool { return p.Name == s.Profile }) {
+		ui.profileSelect.SetSelected(s.Profile)
+	}
+}
+
+// saveSettings records the current UI state and writes it to disk, unless the
+// settings file couldn't be loaded.
+func (ui *AppUI) saveSettings() {
+	ui.settings.Window.FullScreen = ui.window.FullScreen()
+	// Drop a position that can't be restored here, e.g. from a settings file
+	// copied off Windows, so it can't hold back the saved size either.
+	ui.settings.Window.Position = nil
+	if hwnd := windowHandle(ui.window); hwnd != 0 {
+		if pos, ok := nativeWindowPosition(hwnd); ok {
+			ui.settings.Window.Position = &pos
+		}
+	}
+	// Keep the restored size while maximized or full screen.
+	if pos := ui.settings.Window.Position; !ui.settings.Window.FullScreen && (pos == nil || !pos.Maximized) {
+		size := ui.window.Canvas().Size()
+		ui.settings.Window.Width, ui.settings.Window.Height = size.Width, size.Height
+	}
+	ui.settings.Connection.Port = ui.portSelect.Selected
+	if baud, err := strconv.Atoi(ui.baudSelect.Selected); err == nil {
+		ui.settings.Connection.BaudRate = baud
+	}
+	ui.settings.Connection.Framing = ui.framing()
+	ui.settings.Connection.LineEnding = ui.lineEnding
+	ui.settings.Connection.Encoding = ui.encodingSel.Selected
+	ui.settings.Connection.KeepCR = ui.keepCRChk.Checked
+	ui.settings.Profile = ui.profileSelect.Selected
+
+	ui.mu.Lock()
+	ui.settings.Display = DisplaySettings{
+		Autoscroll:    ui.autoscroll,
+		Timestamps:    ui.showTimestamp,
+		TimestampMode: ui.timestampMode,
+		Decoder:       ui.decoder.Name(),
+		TableMode:     ui.tableMode,
+		Invisibles:    ui.invisibles,
+	}
+	ui.mu.Unlock()
+	ui.settings.Templates = ui.savedTemplates
+	ui.settings.Modbus = ui.modbusPolls
+
+	if ui.settingsErr != nil {
+		return
+	}
+	if err := SaveSettings(ui.settings); err != nil {
+		log.Printf("failed to save settings: %v", err)
+	}
+}
+
+// windowHandle returns the native handle of a window on Windows, or 0 on other
+// platforms and before the window is shown.
+func windowHandle(w fyne.Window) uintptr {
+	var hwnd uintptr
+	if nw, ok := w.(driver.NativeWindow); ok {
+		nw.RunNative(func(ctx any) {
+			if c, ok := ctx.(driver.WindowsWindowContext); ok {
+				hwnd = c.HWND
+			}
+		})
+	}
+	return hwnd
+}
+
 func (ui *AppUI) build() {
 	// Port selection
 	ui.portSelect = widget.NewSelect([]string{}, nil)
@@ -68,6 +304,7 @@ func (ui *AppUI) build() {
 
 	ui.refreshBtn = widget.NewButton("Refresh", func() {
 		ui.refreshPorts()
+		ui.autoApplyProfile()
 	})
 	ui.refreshPorts()
 
@@ -75,6 +312,26 @@ func (ui *AppUI) build() {
 	ui.baudSelect = widget.NewSelect(standardBaudRates, nil)
 	ui.baudSelect.SetSelected("9600")
 
+	// Framing selection
+	ui.dataBitsSel = widget.NewSelect(dataBitsNames, nil)
+	ui.paritySel = widget.NewSelect(parityNames, nil)
+	ui.stopBitsSel = widget.NewSelect(stopBitsNames, nil)
+	ui.setFraming(defaultFraming)
+
+	// Connection profiles
+	ui.profileSelect = widget.NewSelect(nil, func(name string) {
+		for _, p := range ui.settings.Profiles {
+			if p.Name == name {
+				ui.applyProfile(p)
+				return
+			}
+		}
+	})
+	ui.profileSelect.PlaceHolder = "(no profile)"
+	ui.profilesBtn = widget.NewButton("Profiles...", func() {
+		ui.showProfilesDialog()
+	})
+
 	// Connect/Disconnect button
 	ui.connectBtn = widget.NewButton("Connect", func() {
 		ui.toggleConnection()
@@ -85,15 +342,104 @@ func (ui *AppUI) build() {
 		ui.mu.Lock()
 		ui.lines = nil
 		ui.displayLines = nil
+		ui.frozenLines = nil
+		ui.frozenDisplay = nil
+		ui.pausedCount = 0
+		ui.selAnchor, ui.selStart, ui.selEnd, ui.selCursor = 0, 0, 0, 0
+		clear(ui.bookmarks)
+		ui.tableView.rebuildLocked()
 		ui.mu.Unlock()
-		ui.output.Refresh()
+		ui.output.UnselectAll()
+		ui.refreshOutput()
 	})
 
 	// Export button
-	ui.exportBtn = widget.NewButton("Export CSV", func() {
+	ui.exportBtn = widget.NewButton("Export", func() {
 		ui.showExportDialog()
 	})
 
+	// Session buttons
+	ui.openSessionBtn = widget.NewButton("Open Session", func() {
+		ui.showOpenSessionDialog()
+	})
+	ui.saveSessionBtn = widget.NewButton("Save Session", func() {
+		ui.showSaveSessionDialog()
+	})
+
+	// Send file button
+	ui.sendFileBtn = widget.NewButton("Send File", func() {
+		ui.showSendFileDialog()
+	})
+
+	// XMODEM/YMODEM transfer button
+	ui.transferBtn = widget.NewButton("Transfer", func() {
+		ui.showTransferDialog()
+	})
+
+	// Modbus RTU master window
+	ui.modbusBtn = widget.NewButton("Modbus", func() {
+		ui.showModbusWindow()
+	})
+
+	// Inter-line latency histogram
+	ui.latencyBtn = widget.NewButton("Latency", func() {
+		ui.showLatencyDialog()
+	})
+
+	// Local HTTP API
+	ui.apiBtn = widget.NewButton("API", func() {
+		ui.showAPIDialog()
+	})
+
+	// Serial-to-TCP bridge
+	ui.bridgeBtn = widget.NewButton("Bridge", func() {
+		ui.showBridgeDialog()
+	})
+
+	// MQTT publishing
+	ui.mqttBtn = widget.NewButton("MQTT", func() {
+		ui.showMQTTDialog()
+	})
+
+	// Output sinks
+	ui.sinksBtn = widget.NewButton("Sinks", func() {
+		ui.showSinksDialog()
+	})
+
+	// Link statistics
+	ui.statsBtn = widget.NewButton("Stats", func() {
+		ui.showStatsDialog()
+	})
+
+	// Fake device on a virtual port
+	ui.simulatorBtn = widget.NewButton("Simulator", func() {
+		ui.showSimulatorDialog()
+	})
+
+	// Freeze the output while capture continues
+	ui.pauseBtn = widget.NewButtonWithIcon("Pause", theme.MediaPauseIcon(), func() {
+		ui.togglePause()
+	})
+	ui.pausedLabel = widget.NewLabel("")
+	ui.pausedLabel.Hide()
+
+	// Copying and bookmarking lines selected in the output
+	ui.copyBtn = widget.NewButtonWithIcon("Copy", theme.ContentCopyIcon(), func() {
+		ui.showCopyMenu()
+	})
+	ui.bookmarkBtn = widget.NewButton("Bookmark", func() {
+		ui.toggleBookmark()
+	})
+	ui.noteBtn = widget.NewButton("Note...", func() {
+		ui.showNoteDialog()
+	})
+	ui.prevMarkBtn = widget.NewButtonWithIcon("", theme.NavigateBackIcon(), func() {
+		ui.jumpToBookmark(-1)
+	})
+	ui.nextMarkBtn = widget.NewButtonWithIcon("", theme.NavigateNextIcon(), func() {
+		ui.jumpToBookmark(1)
+	})
+
 	// Autoscroll checkbox
 	ui.autoscrollChk = widget.NewCheck("Autoscroll", func(checked bool) {
 		ui.mu.Lock()
@@ -108,7 +454,70 @@ func (ui *AppUI) build() {
 		ui.showTimestamp = checked
 		ui.rebuildDisplayLines()
 		ui.mu.Unlock()
-		ui.output.Refresh()
+		ui.refreshOutput()
+	})
+	ui.timestampSel = widget.NewSelect(displayTimestampModes, func(mode string) {
+		ui.mu.Lock()
+		ui.timestampMode = mode
+		ui.rebuildDisplayLines()
+		ui.mu.Unlock()
+		ui.refreshOutput()
+	})
+
+	// Text encoding, applied to display, copy and export
+	ui.encodingSel = widget.NewSelect(encodingNames, func(selected string) {
+		ui.mu.Lock()
+		ui.encoding = selected
+		ui.rebuildDisplayLines()
+		if ui.tableMode {
+			ui.tableView.rebuildLocked()
+		}
+		ui.mu.Unlock()
+		ui.refreshOutput()
+	})
+
+	// Decoder selection
+	ui.decoderSelect = widget.NewSelect(decoderNames(), func(selected string) {
+		d := decoderByName(selected)
+		ui.serial.SetDelimiter(d.Delimiter())
+		ui.mu.Lock()
+		ui.decoder = d
+		ui.rebuildDisplayLines()
+		if ui.tableMode {
+			ui.tableView.rebuildLocked()
+		}
+		ui.mu.Unlock()
+		ui.refreshOutput()
+	})
+
+	// Control characters and trailing spaces, for debugging framing
+	ui.invisiblesSel = widget.NewSelect(invisiblesModes, func(mode string) {
+		ui.mu.Lock()
+		ui.invisibles = mode
+		ui.rebuildDisplayLines()
+		ui.mu.Unlock()
+		ui.refreshOutput()
+	})
+	ui.keepCRChk = widget.NewCheck(`Keep \r`, func(checked bool) {
+		ui.serial.SetKeepCR(checked)
+	})
+
+	// Table view toggle
+	ui.tableChk = widget.NewCheck("Table", func(checked bool) {
+		ui.mu.Lock()
+		ui.tableMode = checked
+		if checked {
+			ui.tableView.rebuildLocked()
+		}
+		ui.mu.Unlock()
+		if checked {
+			ui.output.Hide()
+			ui.tableView.content.Show()
+		} else {
+			ui.tableView.content.Hide()
+			ui.output.Show()
+		}
+		ui.refreshOutput()
 	})
 
 	// Output list — copy the display text outside the lock to avoid deadlock
@@ -117,23 +526,57 @@ func (ui *AppUI) build() {
 		func() int {
 			ui.mu.Lock()
 			defer ui.mu.Unlock()
-			return len(ui.displayLines)
+			_, display := ui.visibleLocked()
+			return len(display)
 		},
 		func() fyne.CanvasObject {
 			label := widget.NewLabel("")
 			label.TextStyle = fyne.TextStyle{Monospace: true}
-			return label
+			note := widget.NewLabel("")
+			note.TextStyle = fyne.TextStyle{Italic: true}
+			marker := canvas.NewRectangle(color.Transparent)
+			marker.SetMinSize(fyne.NewSize(4, 0))
+			background := canvas.NewRectangle(color.Transparent)
+			return container.NewStack(background, container.NewBorder(nil, nil, marker, note, label))
 		},
 		func(id widget.ListItemID, obj fyne.CanvasObject) {
 			ui.mu.Lock()
-			var text string
-			if id < len(ui.displayLines) {
-				text = ui.displayLines[id]
+			lines, display := ui.visibleLocked()
+			var text, note string
+			var bookmarked, selected bool
+			if id < len(display) {
+				text = display[id]
+			}
+			if id < len(lines) {
+				note, bookmarked = ui.bookmarks[lines[id].Seq]
+				selected = ui.inSelectionLocked(lines[id])
 			}
 			ui.mu.Unlock()
-			obj.(*widget.Label).SetText(text)
+
+			item := obj.(*fyne.Container)
+			row := item.Objects[1].(*fyne.Container)
+			row.Objects[0].(*widget.Label).SetText(text)
+			background := item.Objects[0].(*canvas.Rectangle)
+			background.FillColor = color.Transparent
+			if selected {
+				background.FillColor = theme.Color(theme.ColorNameSelection)
+			}
+			background.Refresh()
+			for _, o := range row.Objects[1:] {
+				switch o := o.(type) {
+				case *canvas.Rectangle:
+					o.FillColor = color.Transparent
+					if bookmarked {
+						o.FillColor = theme.Color(theme.ColorNamePrimary)
+					}
+					o.Refresh()
+				case *widget.Label:
+					o.SetText(note)
+				}
+			}
 		},
 	)
+	ui.output.OnSelected = ui.selectLine
 
 	// Layout
 	portRow := container.NewHBox(
@@ -142,36 +585,146 @@ func (ui *AppUI) build() {
 		ui.refreshBtn,
 		widget.NewLabel("Baud:"),
 		ui.baudSelect,
+		ui.dataBitsSel,
+		ui.paritySel,
+		ui.stopBitsSel,
+		ui.encodingSel,
 		ui.connectBtn,
+		widget.NewLabel("Profile:"),
+		ui.profileSelect,
+		ui.profilesBtn,
 	)
 
 	optionsRow := container.NewHBox(
 		ui.autoscrollChk,
 		ui.timestampChk,
+		ui.timestampSel,
+		widget.NewLabel("Decoder:"),
+		ui.decoderSelect,
+		ui.tableChk,
+		widget.NewLabel("Invisibles:"),
+		ui.invisiblesSel,
+		ui.keepCRChk,
 		layout.NewSpacer(),
+		ui.pausedLabel,
+		ui.pauseBtn,
 		ui.clearBtn,
+		ui.sendFileBtn,
+		ui.transferBtn,
+		ui.modbusBtn,
+		ui.latencyBtn,
+		ui.apiBtn,
+		ui.bridgeBtn,
+		ui.mqttBtn,
+		ui.sinksBtn,
+		ui.simulatorBtn,
+		ui.statsBtn,
 		ui.exportBtn,
 	)
 
-	toolbar := container.NewVBox(portRow, optionsRow)
-	content := container.NewBorder(toolbar, nil, nil, nil, ui.output)
+	linesRow := container.NewHBox(
+		ui.copyBtn,
+		ui.bookmarkBtn,
+		ui.noteBtn,
+		widget.NewLabel("Bookmarks:"),
+		ui.prevMarkBtn,
+		ui.nextMarkBtn,
+		layout.NewSpacer(),
+		ui.openSessionBtn,
+		ui.saveSessionBtn,
+	)
+
+	ui.tableView = newTableView(ui)
+	ui.tableView.content.Hide()
+	ui.decoderSelect.SetSelected(ui.decoder.Name())
+	ui.encodingSel.SetSelected(ui.encoding)
+	ui.invisiblesSel.SetSelected(ui.invisibles)
+	ui.timestampSel.SetSelected(ui.timestampMode)
+
+	toolbar := container.NewVBox(portRow, optionsRow, linesRow, ui.buildHealthBanner())
+	content := container.NewBorder(toolbar, nil, nil, nil, container.NewStack(ui.output, ui.tableView.content))
 	ui.window.SetContent(content)
+
+	// Ctrl+C copies the selection as shown; Ctrl+B toggles a bookmark.
+	ui.window.Canvas().AddShortcut(&fyne.ShortcutCopy{}, func(fyne.Shortcut) {
+		ui.mu.Lock()
+		withTimestamps := ui.showTimestamp
+		ui.mu.Unlock()
+		ui.copySelection(withTimestamps)
+	})
+	ui.window.Canvas().AddShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyB, Modifier: fyne.KeyModifierShortcutDefault}, func(fyne.Shortcut) {
+		ui.toggleBookmark()
+	})
 }
 
+// selectLine records the selected line range for copying and export.
+// Shift-click extends the range from the previously clicked line.
+func (ui *AppUI) selectLine(id widget.ListItemID) {
+	shift := false
+	if drv, ok := fyne.CurrentApp().Driver().(desktop.Driver); ok {
+		shift = drv.CurrentKeyModifiers()&fyne.KeyModifierShift != 0
+	}
+
+	ui.mu.Lock()
+	lines, _ := ui.visibleLocked()
+	if id < 0 || id >= len(lines) {
+		ui.mu.Unlock()
+		return
+	}
+	seq := lines[id].Seq
+	ui.selCursor = seq
+	if !shift || ui.selAnchor == 0 {
+		ui.selAnchor = seq
+	}
+	ui.selStart, ui.selEnd = ui.selAnchor, seq
+	if ui.selEnd < ui.selStart {
+		ui.selStart, ui.selEnd = ui.selEnd, ui.selStart
+	}
+	ui.mu.Unlock()
+
+	// Redraw so the whole range is highlighted.
+	ui.output.Refresh()
+}
+
+// refreshPorts reloads the port list, keeping the selected port while it's
+// still there.
 func (ui *AppUI) refreshPorts() {
 	ports := ui.serial.AvailablePorts()
+	// Virtual ports aren't enumerated with the hardware ones.
+	if ui.simulator != nil && !slices.Contains(ports, ui.simulator.PortName()) {
+		ports = append(ports, ui.simulator.PortName())
+	}
 	ui.portSelect.Options = ports
-	if len(ports) > 0 {
+	if len(ports) > 0 && !slices.Contains(ports, ui.portSelect.Selected) {
 		ui.portSelect.SetSelected(ports[0])
 	}
 	ui.portSelect.Refresh()
 }
 
+// framing returns the framing chosen in the toolbar.
+func (ui *AppUI) framing() Framing {
+	bits, err := strconv.Atoi(ui.dataBitsSel.Selected)
+	if err != nil {
+		bits = defaultFraming.DataBits
+	}
+	return Framing{DataBits: bits, Parity: ui.paritySel.Selected, StopBits: ui.stopBitsSel.Selected}
+}
+
+func (ui *AppUI) setFraming(f Framing) {
+	ui.dataBitsSel.SetSelected(strconv.Itoa(f.DataBits))
+	ui.paritySel.SetSelected(f.Parity)
+	ui.stopBitsSel.SetSelected(f.StopBits)
+}
+
 func (ui *AppUI) setDisconnectedState() {
 	ui.connected.Store(false)
 	ui.connectBtn.SetText("Connect")
 	ui.portSelect.Enable()
 	ui.baudSelect.Enable()
+	ui.dataBitsSel.Enable()
+	ui.paritySel.Enable()
+	ui.stopBitsSel.Enable()
+	ui.profileSelect.Enable()
 }
 
 func (ui *AppUI) toggleConnection() {
@@ -193,45 +746,42 @@ func (ui *AppUI) toggleConnection() {
 		return
 	}
 
-	if err := ui.serial.Connect(portName, baudRate); err != nil {
-		dialog.ShowError(fmt.Errorf("failed to connect: %w", err), ui.window)
-		return
+	if err := ui.connect(portName, baudRate, ui.framing()); err != nil {
+		dialog.ShowError(err, ui.window)
+	}
+}
+
+// connect opens a port and starts reading from it. The toolbar should already
+// show the port's settings.
+func (ui *AppUI) connect(portName string, baudRate int, framing Framing) error {
+	if err := ui.serial.Connect(portName, baudRate, framing); err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
 	}
 
+	ui.mu.Lock()
+	ui.connectedAt = time.Now()
+	ui.connects = append(ui.connects, ui.connectedAt)
+	ui.mu.Unlock()
 	ui.connected.Store(true)
 	ui.connectBtn.SetText("Disconnect")
 	ui.portSelect.Disable()
 	ui.baudSelect.Disable()
+	ui.dataBitsSel.Disable()
+	ui.paritySel.Disable()
+	ui.stopBitsSel.Disable()
+	ui.profileSelect.Disable()
 
+	ui.stats.Reconnected()
+	ui.resetHealthAck()
 	ch, errCh := ui.serial.StartReading()
 	go ui.consumeSerial(ch, errCh)
+	return nil
 }
 
 func (ui *AppUI) consumeSerial(ch <-chan SerialLine, errCh <-chan error) {
 	for line := range ch {
-		ui.mu.Lock()
-		ui.lines = append(ui.lines, line)
-
-		// Bound memory
-		if len(ui.lines) > maxLines {
-			ui.lines = ui.lines[len(ui.lines)-maxLines:]
-		}
-
-		ui.displayLines = append(ui.displayLines, ui.formatLine(line))
-		if len(ui.displayLines) > maxLines {
-			ui.displayLines = ui.displayLines[len(ui.displayLines)-maxLines:]
-		}
-
-		shouldScroll := ui.autoscroll
-		count := len(ui.displayLines)
-		ui.mu.Unlock()
-
-		fyne.Do(func() {
-			ui.output.Refresh()
-			if shouldScroll && count > 0 {
-				ui.output.ScrollToBottom()
-			}
-		})
+		ui.stats.Add(line)
+		ui.appendLine(line)
 	}
 
 	// Channel closed — check if there was an error
@@ -248,22 +798,114 @@ func (ui *AppUI) consumeSerial(ch <-chan SerialLine, errCh <-chan error) {
 	}
 }
 
-func (ui *AppUI) formatLine(line SerialLine) string {
+// appendLine adds a received line to the buffer and refreshes the output.
+// Safe to call from any goroutine.
+func (ui *AppUI) appendLine(line SerialLine) {
+	ui.mu.Lock()
+	var prev time.Time
+	if len(ui.lines) > 0 {
+		prev = ui.lines[len(ui.lines)-1].Timestamp
+	}
+	ui.nextSeq++
+	line.Seq = ui.nextSeq
+	ui.lines = append(ui.lines, line)
+
+	// Bound memory
+	if len(ui.lines) > maxLines {
+		ui.lines = ui.lines[len(ui.lines)-maxLines:]
+		ui.pruneBookmarksLocked()
+	}
+
+	ui.displayLines = append(ui.displayLines, ui.formatLine(line, prev))
+	if len(ui.displayLines) > maxLines {
+		ui.displayLines = ui.displayLines[len(ui.displayLines)-maxLines:]
+	}
+	// While paused the table stays as it was; it catches up on resume.
+	if ui.tableMode && !ui.paused {
+		ui.tableView.addLocked(line)
+	}
+	if ui.paused {
+		ui.pausedCount++
+	}
+	ui.mu.Unlock()
+
+	ui.stats.Drop(ui.lineHub.Publish(line) + ui.sinks.Publish(line))
+	ui.scheduleRefresh()
+}
+
+// refreshOutput redraws whichever output view is active, scrolling to the
+// newest line when autoscroll is on and the view isn't paused.
+func (ui *AppUI) refreshOutput() {
+	ui.mu.Lock()
+	shouldScroll := ui.autoscroll && !ui.paused
+	tableMode := ui.tableMode
+	sorted := ui.tableView.sortCol >= 0
+	_, display := ui.visibleLocked()
+	count := len(display)
+	ui.mu.Unlock()
+
+	if tableMode {
+		ui.tableView.refresh()
+		if shouldScroll && !sorted && count > 0 {
+			ui.tableView.table.ScrollToBottom()
+		}
+		return
+	}
+	ui.output.Refresh()
+	if shouldScroll && count > 0 {
+		ui.output.ScrollToBottom()
+	}
+}
+
+// formatLine renders a line for display; prev is the previous line's
+// timestamp for delta mode. Must be called with ui.mu held.
+func (ui *AppUI) formatLine(line SerialLine, prev time.Time) string {
+	text := ui.lineTextLocked(line)
 	if ui.showTimestamp {
-		return fmt.Sprintf("[%s] %s", line.Timestamp.Format("15:04:05.000"), line.Data)
+		return fmt.Sprintf("[%s] %s", formatDisplayTimestamp(line.Timestamp, ui.timestampMode, connectOrigin(ui.connects, line.Timestamp), prev), text)
+	}
+	return text
+}
+
+// lineTextLocked returns a line's content as shown: hex for binary decoders,
+// otherwise text in the chosen encoding. Must be called with ui.mu held.
+func (ui *AppUI) lineTextLocked(line SerialLine) string {
+	if isBinaryDecoder(ui.decoder) {
+		return formatHex(line.Data)
 	}
-	return line.Data
+	return showInvisibles(decodeText(line.Data, ui.encoding), ui.invisibles)
 }
 
 // rebuildDisplayLines regenerates all display strings (called when timestamp toggle changes).
 // Must be called with ui.mu held.
 func (ui *AppUI) rebuildDisplayLines() {
-	ui.displayLines = make([]string, len(ui.lines))
-	for i, line := range ui.lines {
-		ui.displayLines[i] = ui.formatLine(line)
+	ui.displayLines = ui.formatLines(ui.lines)
+	if ui.paused {
+		ui.frozenDisplay = ui.formatLines(ui.frozenLines)
 	}
 }
 
+// formatLines renders lines for display. Must be called with ui.mu held.
+func (ui *AppUI) formatLines(lines []SerialLine) []string {
+	display := make([]string, len(lines))
+	var prev time.Time
+	for i, line := range lines {
+		display[i] = ui.formatLine(line, prev)
+		prev = line.Timestamp
+	}
+	return display
+}
+
+// localPath converts a file URI to an OS path, stripping the leading slash
+// Fyne adds before Windows drive letters.
+func localPath(uri fyne.URI) string {
+	path := uri.Path()
+	if len(path) > 2 && path[0] == '/' && path[2] == ':' {
+		path = path[1:]
+	}
+	return path
+}
+
 func (ui *AppUI) showExportDialog() {
 	ui.mu.Lock()
 	lineCount := len(ui.lines)
@@ -274,46 +916,122 @@ func (ui *AppUI) showExportDialog() {
 		return
 	}
 
+	// Format and per-format options
+	csvDelimiterSelect := widget.NewSelect(csvDelimiterNames, nil)
+	csvDelimiterSelect.SetSelected(ui.settings.Export.CSVDelimiter)
+	jsonlRawCheck := widget.NewCheck("Include raw line", nil)
+	jsonlRawCheck.SetChecked(ui.settings.Export.JSONLIncludeRaw)
+	xlsxSheetEntry := widget.NewEntry()
+	xlsxSheetEntry.SetText(ui.settings.Export.XLSXSheetName)
+	xlsxFreezeCheck := widget.NewCheck("Freeze header row", nil)
+	xlsxFreezeCheck.SetChecked(ui.settings.Export.XLSXFreezeHeader)
+	parquetCompressionSelect := widget.NewSelect(parquetCompressionNames, nil)
+	parquetCompressionSelect.SetSelected(ui.settings.Export.ParquetCompression)
+
+	formatOptions := map[string]fyne.CanvasObject{
+		FormatCSV:     widget.NewForm(widget.NewFormItem("Delimiter", csvDelimiterSelect)),
+		FormatJSONL:   jsonlRawCheck,
+		FormatXLSX:    widget.NewForm(widget.NewFormItem("Sheet", xlsxSheetEntry), widget.NewFormItem("", xlsxFreezeCheck)),
+		FormatParquet: widget.NewForm(widget.NewFormItem("Compression", parquetCompressionSelect)),
+	}
+	formatOptionsBox := container.NewStack()
+	formatSelect := widget.NewSelect(exportFormats(), func(format string) {
+		formatOptionsBox.Objects = []fyne.CanvasObject{formatOptions[format]}
+		formatOptionsBox.Refresh()
+	})
+	formatSelect.SetSelected(exporterByFormat(ui.settings.Export.Format).Format())
+
+	// Parsing options
+	inputDelimiterSelect := widget.NewSelect(csvDelimiterNames, nil)
+	inputDelimiterSelect.SetSelected(ui.settings.Export.InputDelimiter)
+	trimFieldsCheck := widget.NewCheck("Trim whitespace", nil)
+	trimFieldsCheck.SetChecked(ui.settings.Export.TrimFields)
+	ui.mu.Lock()
+	if _, ok := ui.decoder.(csvDecoder); !ok {
+		inputDelimiterSelect.Disable()
+		trimFieldsCheck.Disable()
+	}
+	ui.mu.Unlock()
+	mismatchSelect := widget.NewSelect(mismatchPolicies, nil)
+	mismatchSelect.SetSelected(ui.settings.Export.Mismatch)
+	inferTypesCheck := widget.NewCheck("Infer numeric types (JSON Lines, XLSX, Parquet)", nil)
+	inferTypesCheck.SetChecked(ui.settings.Export.InferTypes)
+
 	// Options
 	includeTimestamps := widget.NewCheck("Include timestamps", nil)
 
-	filterByTime := widget.NewCheck("Filter by time range", nil)
+	timestampFormatSelect := widget.NewSelect(timestampFormats, nil)
+	timestampFormatSelect.SetSelected(ui.settings.Export.TimestampFormat)
+	timestampFormatSelect.Disable()
+	includeFirstByte := widget.NewCheck("First-byte time", nil)
+	includeFirstByte.Disable()
+	includeTimestamps.OnChanged = func(checked bool) {
+		if checked {
+			timestampFormatSelect.Enable()
+			includeFirstByte.Enable()
+		} else {
+			timestampFormatSelect.Disable()
+			includeFirstByte.Disable()
+		}
+	}
+	includeFirstByte.SetChecked(ui.settings.Export.IncludeFirstByte)
+	includeTimestamps.SetChecked(ui.settings.Export.IncludeTimestamps)
+	includeBookmarks := widget.NewCheck("Include bookmark column with notes", nil)
+	includeBookmarks.SetChecked(ui.settings.Export.IncludeBookmarks)
 
 	startEntry := widget.NewEntry()
-	startEntry.SetPlaceHolder("Start (HH:MM:SS)")
+	startEntry.SetPlaceHolder("Start ([YYYY-MM-DD] HH:MM:SS[.mmm])")
 	startEntry.Disable()
 
 	endEntry := widget.NewEntry()
-	endEntry.SetPlaceHolder("End (HH:MM:SS)")
+	endEntry.SetPlaceHolder("End ([YYYY-MM-DD] HH:MM:SS[.mmm])")
 	endEntry.Disable()
 
-	filterByTime.OnChanged = func(checked bool) {
-		if checked {
+	spanEntry := widget.NewEntry()
+	spanEntry.SetPlaceHolder("e.g. 30s, 5m, 2 hours")
+	spanEntry.Disable()
+
+	rangeSelect := widget.NewSelect(timeRangeModes, func(mode string) {
+		startEntry.Disable()
+		endEntry.Disable()
+		spanEntry.Disable()
+		switch mode {
+		case RangeAbsolute:
 			startEntry.Enable()
 			endEntry.Enable()
-		} else {
-			startEntry.Disable()
-			endEntry.Disable()
+		case RangeLast, RangeFirst:
+			spanEntry.Enable()
 		}
-	}
+	})
+	rangeSelect.SetSelected(RangeAll)
 
 	// Header source selection: None, Template, Paste, File
 	headerSourceSelect := widget.NewSelect([]string{"None", "Template", "Paste", "File"}, nil)
-	headerSourceSelect.SetSelected("None")
 
 	// User-saved templates
-	headerTemplateSelect := widget.NewSelect(ui.savedTemplates, nil)
+	headerTemplateSelect := widget.NewSelect(templateNames(ui.savedTemplates), nil)
 	headerTemplateSelect.PlaceHolder = "Select saved template..."
+	if ui.headerTemplate != "" {
+		headerTemplateSelect.SetSelected(ui.headerTemplate)
+	}
 	headerTemplateSelect.Disable()
 
-	saveTemplateBtn := widget.NewButton("Save Current as Template", nil)
+	editTemplatesBtn := widget.NewButton("Edit Templates...", func() {
+		ui.showTemplateEditor(func() {
+			headerTemplateSelect.Options = templateNames(ui.savedTemplates)
+			if !slices.Contains(headerTemplateSelect.Options, headerTemplateSelect.Selected) {
+				headerTemplateSelect.ClearSelected()
+			}
+			headerTemplateSelect.Refresh()
+		})
+	})
+
+	saveTemplateBtn := widget.NewButton("Save as Template", nil)
 	saveTemplateBtn.Disable()
-	deleteTemplateBtn := widget.NewButton("Delete Selected", nil)
-	deleteTemplateBtn.Disable()
 
 	// Paste entry
 	headerPasteEntry := widget.NewEntry()
-	headerPasteEntry.SetPlaceHolder("e.g. Time,Temp,Humidity")
+	headerPasteEntry.SetPlaceHolder("e.g. Temp,Humidity")
 	headerPasteEntry.Disable()
 
 	// Save template from paste entry
@@ -323,36 +1041,12 @@ func (ui *AppUI) showExportDialog() {
 			dialog.ShowInformation("Template", "Enter a header in the Paste field first.", ui.window)
 			return
 		}
-		// Avoid duplicates
-		for _, t := range ui.savedTemplates {
-			if t == text {
-				dialog.ShowInformation("Template", "This template already exists.", ui.window)
-				return
-			}
-		}
-		ui.savedTemplates = append(ui.savedTemplates, text)
-		SaveTemplates(ui.savedTemplates)
-		headerTemplateSelect.Options = ui.savedTemplates
-		headerTemplateSelect.Refresh()
-		dialog.ShowInformation("Template", "Template saved.", ui.window)
-	}
-
-	// Delete selected template
-	deleteTemplateBtn.OnTapped = func() {
-		sel := headerTemplateSelect.Selected
-		if sel == "" {
-			return
-		}
-		for i, t := range ui.savedTemplates {
-			if t == sel {
-				ui.savedTemplates = append(ui.savedTemplates[:i], ui.savedTemplates[i+1:]...)
-				break
-			}
-		}
-		SaveTemplates(ui.savedTemplates)
-		headerTemplateSelect.Options = ui.savedTemplates
-		headerTemplateSelect.ClearSelected()
+		t := templateFromHeader(uniqueTemplateName(ui.savedTemplates, text), text)
+		ui.savedTemplates = append(ui.savedTemplates, t)
+		ui.templatesChanged()
+		headerTemplateSelect.Options = templateNames(ui.savedTemplates)
 		headerTemplateSelect.Refresh()
+		dialog.ShowInformation("Template", fmt.Sprintf("Saved as template %q.", t.Name), ui.window)
 	}
 
 	// File browse
@@ -366,10 +1060,7 @@ func (ui *AppUI) showExportDialog() {
 			if err != nil || reader == nil {
 				return
 			}
-			customHeaderPath = reader.URI().Path()
-			if len(customHeaderPath) > 2 && customHeaderPath[0] == '/' && customHeaderPath[2] == ':' {
-				customHeaderPath = customHeaderPath[1:]
-			}
+			customHeaderPath = localPath(reader.URI())
 			headerPathLabel.SetText(reader.URI().Name())
 			reader.Close()
 		}, ui.window)
@@ -381,11 +1072,9 @@ func (ui *AppUI) showExportDialog() {
 		headerPasteEntry.Disable()
 		headerBrowseBtn.Disable()
 		saveTemplateBtn.Disable()
-		deleteTemplateBtn.Disable()
 		switch selected {
 		case "Template":
 			headerTemplateSelect.Enable()
-			deleteTemplateBtn.Enable()
 		case "Paste":
 			headerPasteEntry.Enable()
 			saveTemplateBtn.Enable()
@@ -393,59 +1082,110 @@ func (ui *AppUI) showExportDialog() {
 			headerBrowseBtn.Enable()
 		}
 	}
+	headerSourceSelect.SetSelected(ui.settings.Export.HeaderSource)
+
+	mismatchItem := widget.NewFormItem("Field Count Mismatch", mismatchSelect)
+	mismatchItem.HintText = "Pad fills short rows with empty cells and keeps extra fields"
+	headerSourceItem := widget.NewFormItem("Header Source", headerSourceSelect)
+	headerSourceItem.HintText = "Name the data columns only; Timestamp, First Byte and Bookmark columns are added automatically"
 
 	form := widget.NewForm(
-		widget.NewFormItem("Timestamps", includeTimestamps),
-		widget.NewFormItem("Time Filter", filterByTime),
+		widget.NewFormItem("Format", formatSelect),
+		widget.NewFormItem("Format Options", formatOptionsBox),
+		widget.NewFormItem("Input Delimiter", container.NewHBox(inputDelimiterSelect, trimFieldsCheck)),
+		mismatchItem,
+		widget.NewFormItem("Types", inferTypesCheck),
+		widget.NewFormItem("Timestamps", container.NewHBox(includeTimestamps, timestampFormatSelect, includeFirstByte)),
+		widget.NewFormItem("Bookmarks", includeBookmarks),
+		widget.NewFormItem("Time Range", rangeSelect),
 		widget.NewFormItem("Start", startEntry),
 		widget.NewFormItem("End", endEntry),
-		widget.NewFormItem("Header Source", headerSourceSelect),
-		widget.NewFormItem("Template", container.NewHBox(headerTemplateSelect, deleteTemplateBtn)),
+		widget.NewFormItem("Span", spanEntry),
+		headerSourceItem,
+		widget.NewFormItem("Template", container.NewHBox(headerTemplateSelect, editTemplatesBtn)),
 		widget.NewFormItem("Paste Header", container.NewVBox(headerPasteEntry, saveTemplateBtn)),
 		widget.NewFormItem("Header File", container.NewHBox(headerPathLabel, headerBrowseBtn)),
 	)
 
-	dialog.ShowCustomConfirm("Export CSV Options", "Export", "Cancel", form, func(confirmed bool) {
+	dialog.ShowCustomConfirm("Export Options", "Export", "Cancel", form, func(confirmed bool) {
 		if !confirmed {
 			return
 		}
 
+		ui.mu.Lock()
+		decoder := ui.decoder
+		encoding := ui.encoding
+		connects := slices.Clone(ui.connects)
+		var bookmarks map[uint64]string
+		if includeBookmarks.Checked {
+			bookmarks = maps.Clone(ui.bookmarks)
+		}
+		ui.mu.Unlock()
+		if _, ok := decoder.(csvDecoder); ok {
+			decoder = csvDecoder{Comma: csvDelimiters[inputDelimiterSelect.Selected], Trim: trimFieldsCheck.Checked}
+		}
+
 		// Build export options
-		opts := CSVExportOptions{
+		opts := ExportOptions{
+			Format:            formatSelect.Selected,
 			IncludeTimestamps: includeTimestamps.Checked,
-			FilterByTime:      filterByTime.Checked,
+			IncludeFirstByte:  includeFirstByte.Checked,
+			FilterByTime:      rangeSelect.Selected != RangeAll && rangeSelect.Selected != RangeSelection,
+			TimestampFormat:   timestampFormatSelect.Selected,
+			Connects:          connects,
+			Decoder:           decoder,
+			Mismatch:          mismatchSelect.Selected,
+			InferTypes:        inferTypesCheck.Checked,
+			Bookmarks:         bookmarks,
+			Encoding:          encoding,
+			CSV:               CSVOptions{Delimiter: csvDelimiters[csvDelimiterSelect.Selected]},
+			JSONL:             JSONLOptions{IncludeRaw: jsonlRawCheck.Checked},
+			XLSX:              XLSXOptions{SheetName: strings.TrimSpace(xlsxSheetEntry.Text), FreezeHeader: xlsxFreezeCheck.Checked},
+			Parquet:           ParquetOptions{Compression: parquetCompressionSelect.Selected},
 		}
 
-		if filterByTime.Checked {
-			now := time.Now()
-			startText := strings.TrimSpace(startEntry.Text)
-			endText := strings.TrimSpace(endEntry.Text)
+		ui.settings.Export = ExportSettings{
+			Format:             formatSelect.Selected,
+			IncludeTimestamps:  includeTimestamps.Checked,
+			IncludeFirstByte:   includeFirstByte.Checked,
+			IncludeBookmarks:   includeBookmarks.Checked,
+			TimestampFormat:    timestampFormatSelect.Selected,
+			InputDelimiter:     inputDelimiterSelect.Selected,
+			TrimFields:         trimFieldsCheck.Checked,
+			Mismatch:           mismatchSelect.Selected,
+			InferTypes:         inferTypesCheck.Checked,
+			HeaderSource:       headerSourceSelect.Selected,
+			CSVDelimiter:       csvDelimiterSelect.Selected,
+			JSONLIncludeRaw:    jsonlRawCheck.Checked,
+			XLSXSheetName:      xlsxSheetEntry.Text,
+			XLSXFreezeHeader:   xlsxFreezeCheck.Checked,
+			ParquetCompression: parquetCompressionSelect.Selected,
+		}
+		ui.saveSettings()
 
-			if startText != "" {
-				t, err := time.Parse("15:04:05", startText)
-				if err != nil {
-					dialog.ShowError(fmt.Errorf("invalid start time format (use HH:MM:SS): %s", startText), ui.window)
-					return
-				}
-				opts.StartTime = time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.Local)
+		if opts.FilterByTime {
+			start, end, err := ui.exportTimeRange(rangeSelect.Selected, startEntry.Text, endEntry.Text, spanEntry.Text)
+			if err != nil {
+				dialog.ShowError(err, ui.window)
+				return
 			}
-			if endText != "" {
-				t, err := time.Parse("15:04:05", endText)
-				if err != nil {
-					dialog.ShowError(fmt.Errorf("invalid end time format (use HH:MM:SS): %s", endText), ui.window)
-					return
-				}
-				opts.EndTime = time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.Local)
-			} else {
-				// No end time specified — include everything up to now
-				opts.EndTime = now
+			opts.StartTime, opts.EndTime = start, end
+		}
+		if rangeSelect.Selected == RangeSelection {
+			ui.mu.Lock()
+			opts.FilterBySeq, opts.StartSeq, opts.EndSeq = true, ui.selStart, ui.selEnd
+			ui.mu.Unlock()
+			if opts.StartSeq == 0 {
+				dialog.ShowError(fmt.Errorf("select a line in the output (shift-click to extend the range)"), ui.window)
+				return
 			}
 		}
 
 		switch headerSourceSelect.Selected {
 		case "Template":
-			if headerTemplateSelect.Selected != "" {
-				opts.CustomHeader = strings.Split(headerTemplateSelect.Selected, ",")
+			if t, ok := templateByName(ui.savedTemplates, headerTemplateSelect.Selected); ok {
+				opts.CustomHeader = t.Header()
+				opts.CustomTypes = t.Types()
 			}
 		case "Paste":
 			text := strings.TrimSpace(headerPasteEntry.Text)
@@ -473,25 +1213,390 @@ func (ui *AppUI) showExportDialog() {
 			}
 			writer.Close()
 
-			savePath := writer.URI().Path()
-			if len(savePath) > 2 && savePath[0] == '/' && savePath[2] == ':' {
-				savePath = savePath[1:]
+			opts.FilePath = localPath(writer.URI())
+			// A recognised extension overrides the selector, so "log.xlsx"
+			// is always a workbook.
+			if e, ok := exporterForPath(opts.FilePath); ok {
+				opts.Format = e.Format()
 			}
-			opts.FilePath = savePath
 
 			ui.mu.Lock()
 			linesCopy := make([]SerialLine, len(ui.lines))
 			copy(linesCopy, ui.lines)
+			tableMode := ui.tableMode
 			ui.mu.Unlock()
 
-			if err := ExportCSV(linesCopy, opts); err != nil {
+			// Export exactly what the table shows: its row order, visible
+			// columns and headers.
+			if tableMode {
+				lines, columns, header := ui.tableView.exportView()
+				linesCopy = lines
+				opts.Columns = columns
+				if len(opts.CustomHeader) == 0 {
+					opts.CustomHeader = header
+				}
+			}
+
+			result, err := Export(linesCopy, opts)
+			if err != nil {
+				dialog.ShowError(err, ui.window)
+				return
+			}
+
+			msg := fmt.Sprintf("Exported %d lines to %s.", result.Rows, opts.Format)
+			if result.Mismatched > 0 {
+				msg += fmt.Sprintf("\n%d rows had a field count different from the header", result.Mismatched)
+				if result.Skipped > 0 {
+					msg += fmt.Sprintf(" and %d were skipped", result.Skipped)
+				}
+				msg += "."
+			}
+			if len(result.Untyped) > 0 {
+				msg += fmt.Sprintf("\nSome values didn't match their template type, so these columns were exported as text: %s.", strings.Join(result.Untyped, ", "))
+			}
+			if result.Undecoded > 0 {
+				msg += fmt.Sprintf("\n%d lines could not be decoded as %s and were left out.", result.Undecoded, opts.Decoder.Name())
+			}
+			dialog.ShowInformation("Export", msg, ui.window)
+		}, ui.window)
+		fd.SetFileName("serial_data" + exporterByFormat(opts.Format).Extensions()[0])
+		fd.Show()
+	}, ui.window)
+}
+
+// exportTimeRange turns the export dialog's range choice into start and end
+// times.
+func (ui *AppUI) exportTimeRange(mode, startText, endText, spanText string) (start, end time.Time, err error) {
+	ui.mu.Lock()
+	var first, last time.Time
+	if len(ui.lines) > 0 {
+		first, last = ui.lines[0].Timestamp, ui.lines[len(ui.lines)-1].Timestamp
+	}
+	connectedAt := ui.connectedAt
+	ui.mu.Unlock()
+
+	switch mode {
+	case RangeAbsolute:
+		return resolveTimeRange(startText, endText, first)
+	case RangeLast:
+		span, err := parseRelativeDuration(spanText)
+		if err != nil {
+			return start, end, err
+		}
+		start, end := lastSpan(span, last)
+		return start, end, nil
+	case RangeFirst:
+		span, err := parseRelativeDuration(spanText)
+		if err != nil {
+			return start, end, err
+		}
+		if connectedAt.IsZero() {
+			return start, end, fmt.Errorf("no connection has been made yet")
+		}
+		return connectedAt, connectedAt.Add(span), nil
+	}
+	return start, end, nil
+}
+
+func (ui *AppUI) showSendFileDialog() {
+	if !ui.connected.Load() {
+		dialog.ShowInformation("Send File", "Connect to a port first.", ui.window)
+		return
+	}
+
+	var filePath string
+	pathLabel := widget.NewLabel("No file selected")
+	browseBtn := widget.NewButton("Browse...", func() {
+		fd := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil || reader == nil {
+				return
+			}
+			filePath = localPath(reader.URI())
+			pathLabel.SetText(reader.URI().Name())
+			reader.Close()
+		}, ui.window)
+		fd.Show()
+	})
+
+	lineEndingSelect := widget.NewSelect(lineEndingNames, nil)
+	lineEndingSelect.SetSelected(ui.lineEnding)
+
+	modeSelect := widget.NewSelect([]string{"Text", "Binary"}, func(selected string) {
+		if selected == "Binary" {
+			lineEndingSelect.Disable()
+		} else {
+			lineEndingSelect.Enable()
+		}
+	})
+	modeSelect.SetSelected("Text")
+
+	chunkEntry := widget.NewEntry()
+	chunkEntry.SetText("64")
+
+	delayEntry := widget.NewEntry()
+	delayEntry.SetText("0")
+
+	promptEntry := widget.NewEntry()
+	promptEntry.SetPlaceHolder("e.g. >")
+	promptEntry.Disable()
+
+	timeoutEntry := widget.NewEntry()
+	timeoutEntry.SetText("1000")
+	timeoutEntry.Disable()
+
+	pacingSelect := widget.NewSelect(pacingModes, func(selected string) {
+		promptEntry.Disable()
+		timeoutEntry.Disable()
+		switch selected {
+		case PacingEcho:
+			timeoutEntry.Enable()
+		case PacingPrompt:
+			promptEntry.Enable()
+			timeoutEntry.Enable()
+		}
+	})
+	pacingSelect.SetSelected(PacingNone)
+
+	form := widget.NewForm(
+		widget.NewFormItem("File", container.NewHBox(pathLabel, browseBtn)),
+		widget.NewFormItem("Mode", modeSelect),
+		widget.NewFormItem("Line Ending", lineEndingSelect),
+		widget.NewFormItem("Chunk Size (bytes)", chunkEntry),
+		widget.NewFormItem("Delay (ms)", delayEntry),
+		widget.NewFormItem("Pacing", pacingSelect),
+		widget.NewFormItem("Prompt", promptEntry),
+		widget.NewFormItem("Wait Timeout (ms)", timeoutEntry),
+	)
+
+	dialog.ShowCustomConfirm("Send File", "Send", "Cancel", form, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+		if filePath == "" {
+			dialog.ShowError(fmt.Errorf("no file selected"), ui.window)
+			return
+		}
+
+		chunkSize, err := strconv.Atoi(strings.TrimSpace(chunkEntry.Text))
+		if err != nil || chunkSize <= 0 {
+			dialog.ShowError(fmt.Errorf("invalid chunk size: %s", chunkEntry.Text), ui.window)
+			return
+		}
+		delayMs, err := strconv.Atoi(strings.TrimSpace(delayEntry.Text))
+		if err != nil || delayMs < 0 {
+			dialog.ShowError(fmt.Errorf("invalid delay: %s", delayEntry.Text), ui.window)
+			return
+		}
+		timeoutMs, err := strconv.Atoi(strings.TrimSpace(timeoutEntry.Text))
+		if err != nil || timeoutMs <= 0 {
+			dialog.ShowError(fmt.Errorf("invalid wait timeout: %s", timeoutEntry.Text), ui.window)
+			return
+		}
+
+		opts := SendFileOptions{
+			FilePath:    filePath,
+			Binary:      modeSelect.Selected == "Binary",
+			LineEnding:  lineEndingSelect.Selected,
+			ChunkSize:   chunkSize,
+			Delay:       time.Duration(delayMs) * time.Millisecond,
+			Pacing:      pacingSelect.Selected,
+			Prompt:      promptEntry.Text,
+			WaitTimeout: time.Duration(timeoutMs) * time.Millisecond,
+		}
+		ui.runSendFile(opts)
+	}, ui.window)
+}
+
+// runSendFile sends a file in the background while showing a progress dialog
+// whose Cancel button aborts the transfer.
+func (ui *AppUI) runSendFile(opts SendFileOptions) {
+	bar := widget.NewProgressBar()
+	status := widget.NewLabel("Starting...")
+	progressDlg := dialog.NewCustom("Sending File", "Cancel", container.NewVBox(status, bar), ui.window)
+
+	cancel := make(chan struct{})
+	var cancelOnce sync.Once
+	progressDlg.SetOnClosed(func() {
+		cancelOnce.Do(func() { close(cancel) })
+	})
+	progressDlg.Show()
+
+	go func() {
+		err := SendFile(ui.serial, opts, func(sent, total int) {
+			fyne.Do(func() {
+				if total > 0 {
+					bar.SetValue(float64(sent) / float64(total))
+				}
+				status.SetText(fmt.Sprintf("Sent %d of %d bytes", sent, total))
+			})
+		}, cancel)
+
+		fyne.Do(func() {
+			select {
+			case <-cancel:
+				// User cancelled; dialog is already closed.
+				return
+			default:
+			}
+			progressDlg.Hide()
+			if err != nil {
 				dialog.ShowError(err, ui.window)
 				return
 			}
+			dialog.ShowInformation("Send File", "File sent.", ui.window)
+		})
+	}()
+}
+
+func (ui *AppUI) showTransferDialog() {
+	if !ui.connected.Load() {
+		dialog.ShowInformation("Transfer", "Connect to a port first.", ui.window)
+		return
+	}
+
+	protocolSelect := widget.NewSelect(transferProtocols, nil)
+	protocolSelect.SetSelected(ProtocolXmodemCRC)
+	directionSelect := widget.NewSelect([]string{"Send", "Receive"}, nil)
+	directionSelect.SetSelected("Send")
 
-			dialog.ShowInformation("Export", fmt.Sprintf("Exported %d lines to CSV.", len(linesCopy)), ui.window)
+	var files []string
+	filesLabel := widget.NewLabel("No file selected")
+	addFileBtn := widget.NewButton("Add File...", func() {
+		fd := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil || reader == nil {
+				return
+			}
+			path := localPath(reader.URI())
+			reader.Close()
+			// Only YMODEM can send a batch; XMODEM replaces the selection.
+			if protocolSelect.Selected == ProtocolYmodem {
+				files = append(files, path)
+			} else {
+				files = []string{path}
+			}
+			names := make([]string, len(files))
+			for i, f := range files {
+				names[i] = filepath.Base(f)
+			}
+			filesLabel.SetText(strings.Join(names, ", "))
+		}, ui.window)
+		fd.Show()
+	})
+	clearFilesBtn := widget.NewButton("Clear", func() {
+		files = nil
+		filesLabel.SetText("No file selected")
+	})
+
+	directionSelect.OnChanged = func(selected string) {
+		if selected == "Send" {
+			addFileBtn.Enable()
+			clearFilesBtn.Enable()
+		} else {
+			addFileBtn.Disable()
+			clearFilesBtn.Disable()
+		}
+	}
+
+	form := widget.NewForm(
+		widget.NewFormItem("Protocol", protocolSelect),
+		widget.NewFormItem("Direction", directionSelect),
+		widget.NewFormItem("Files", container.NewHBox(filesLabel, addFileBtn, clearFilesBtn)),
+	)
+
+	dialog.ShowCustomConfirm("File Transfer", "Start", "Cancel", form, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+		opts := TransferOptions{Protocol: protocolSelect.Selected, Files: files}
+
+		if directionSelect.Selected == "Send" {
+			if len(files) == 0 {
+				dialog.ShowError(fmt.Errorf("no file selected"), ui.window)
+				return
+			}
+			ui.runTransfer(opts, false)
+			return
+		}
+
+		if opts.Protocol == ProtocolYmodem {
+			fd := dialog.NewFolderOpen(func(dir fyne.ListableURI, err error) {
+				if err != nil || dir == nil {
+					return
+				}
+				opts.SavePath = localPath(dir)
+				ui.runTransfer(opts, true)
+			}, ui.window)
+			fd.Show()
+			return
+		}
+
+		fd := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+			if err != nil || writer == nil {
+				return
+			}
+			writer.Close()
+			opts.SavePath = localPath(writer.URI())
+			ui.runTransfer(opts, true)
 		}, ui.window)
-		fd.SetFileName("serial_data.csv")
 		fd.Show()
 	}, ui.window)
 }
+
+// runTransfer pauses the line reader and runs an XMODEM/YMODEM transfer with
+// a progress dialog. Line display resumes when the transfer finishes.
+func (ui *AppUI) runTransfer(opts TransferOptions, receive bool) {
+	bar := widget.NewProgressBar()
+	status := widget.NewLabel("Waiting for remote...")
+	retries := widget.NewLabel("Retries: 0")
+	progressDlg := dialog.NewCustom(opts.Protocol+" Transfer", "Cancel", container.NewVBox(status, bar, retries), ui.window)
+
+	cancel := make(chan struct{})
+	var cancelOnce sync.Once
+	progressDlg.SetOnClosed(func() {
+		cancelOnce.Do(func() { close(cancel) })
+	})
+	progressDlg.Show()
+
+	progress := func(p TransferProgress) {
+		fyne.Do(func() {
+			if p.Total > 0 {
+				bar.SetValue(float64(p.Bytes) / float64(p.Total))
+				status.SetText(fmt.Sprintf("%s: %d of %d bytes", p.File, p.Bytes, p.Total))
+			} else {
+				status.SetText(fmt.Sprintf("%s: %d bytes", p.File, p.Bytes))
+			}
+			retries.SetText(fmt.Sprintf("Retries: %d", p.Retries))
+		})
+	}
+
+	go func() {
+		var saved []string
+		err := ui.serial.Exclusive(func(port io.ReadWriter) error {
+			var err error
+			if receive {
+				saved, err = ReceiveFiles(port, opts, progress, cancel)
+			} else {
+				err = SendFiles(port, opts, progress, cancel)
+			}
+			return err
+		})
+
+		fyne.Do(func() {
+			select {
+			case <-cancel:
+				return
+			default:
+			}
+			progressDlg.Hide()
+			if err != nil {
+				dialog.ShowError(fmt.Errorf("transfer failed: %w", err), ui.window)
+				return
+			}
+			if receive {
+				dialog.ShowInformation("Transfer", fmt.Sprintf("Received %d file(s).", len(saved)), ui.window)
+			} else {
+				dialog.ShowInformation("Transfer", "Transfer complete.", ui.window)
+			}
+		})
+	}()
+}
@@ -0,0 +1,13 @@
+//go:build !windows
+
+package main
+
+// Fyne has no API for window position, and only the Windows placement calls
+// are wired up, so elsewhere the window manager decides and no position is
+// saved.
+
+func nativeWindowPosition(hwnd uintptr) (WindowPosition, bool) {
+	return WindowPosition{}, false
+}
+
+func setNativeWindowPosition(hwnd uintptr, p WindowPosition) {}
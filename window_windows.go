@@ -0,0 +1,68 @@
+//go:build windows
+
+package main
+
+import (
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	user32                 = windows.NewLazySystemDLL("user32.dll")
+	procGetWindowPlacement = user32.NewProc("GetWindowPlacement")
+	procSetWindowPlacement = user32.NewProc("SetWindowPlacement")
+)
+
+const (
+	swShowNormal    = 1
+	swShowMaximized = 3
+)
+
+// windowPlacement is the Win32 WINDOWPLACEMENT structure.
+type windowPlacement struct {
+	length         uint32
+	flags          uint32
+	showCmd        uint32
+	minPosition    [2]int32
+	maxPosition    [2]int32
+	normalPosition windows.Rect
+}
+
+func getWindowPlacement(hwnd uintptr) (windowPlacement, bool) {
+	wp := windowPlacement{length: uint32(unsafe.Sizeof(windowPlacement{}))}
+	r, _, _ := procGetWindowPlacement.Call(hwnd, uintptr(unsafe.Pointer(&wp)))
+	return wp, r != 0
+}
+
+// nativeWindowPosition returns where the window sits when not maximized, and
+// whether it is maximized.
+func nativeWindowPosition(hwnd uintptr) (WindowPosition, bool) {
+	wp, ok := getWindowPlacement(hwnd)
+	if !ok {
+		return WindowPosition{}, false
+	}
+	return WindowPosition{
+		X:         int(wp.normalPosition.Left),
+		Y:         int(wp.normalPosition.Top),
+		Maximized: wp.showCmd == swShowMaximized,
+	}, true
+}
+
+// setNativeWindowPosition moves the window to p, keeping its size. Windows
+// pulls a window that would be off every monitor back into view.
+func setNativeWindowPosition(hwnd uintptr, p WindowPosition) {
+	wp, ok := getWindowPlacement(hwnd)
+	if !ok {
+		return
+	}
+	r := &wp.normalPosition
+	width, height := r.Right-r.Left, r.Bottom-r.Top
+	r.Left, r.Top = int32(p.X), int32(p.Y)
+	r.Right, r.Bottom = r.Left+width, r.Top+height
+	wp.showCmd = swShowNormal
+	if p.Maximized {
+		wp.showCmd = swShowMaximized
+	}
+	procSetWindowPlacement.Call(hwnd, uintptr(unsafe.Pointer(&wp)))
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// XLSXOptions configures the Excel exporter.
+type XLSXOptions struct {
+	SheetName    string // "Serial Data" if empty
+	FreezeHeader bool   // keep the header row visible while scrolling
+}
+
+// xlsxExporter writes an Excel workbook with a single sheet.
+type xlsxExporter struct{}
+
+func (xlsxExporter) Format() string       { return FormatXLSX }
+func (xlsxExporter) Extensions() []string { return []string{".xlsx"} }
+
+func (xlsxExporter) Write(path string, table *ExportTable, opts ExportOptions) error {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	sheet := opts.XLSX.SheetName
+	if sheet == "" {
+		sheet = "Serial Data"
+	}
+	if err := f.SetSheetName("Sheet1", sheet); err != nil {
+		return fmt.Errorf("invalid sheet name: %w", err)
+	}
+
+	sw, err := f.NewStreamWriter(sheet)
+	if err != nil {
+		return fmt.Errorf("failed to create sheet: %w", err)
+	}
+	if opts.XLSX.FreezeHeader {
+		if err := sw.SetPanes(&excelize.Panes{
+			Freeze:      true,
+			YSplit:      1,
+			TopLeftCell: "A2",
+			ActivePane:  "bottomLeft",
+		}); err != nil {
+			return fmt.Errorf("failed to freeze header: %w", err)
+		}
+	}
+
+	bold, err := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	if err != nil {
+		return fmt.Errorf("failed to create header style: %w", err)
+	}
+	header := make([]interface{}, len(table.Header))
+	for i, name := range table.Header {
+		header[i] = excelize.Cell{StyleID: bold, Value: name}
+	}
+	if err := sw.SetRow("A1", header); err != nil {
+		return fmt.Errorf("failed to write header: %w", err)
+	}
+
+	for i, record := range table.Rows {
+		cell, _ := excelize.CoordinatesToCellName(1, i+2)
+		values := make([]interface{}, len(record))
+		for j, v := range record {
+			values[j] = v
+			if typ := table.columnType(j); typ != ColumnString {
+				values[j], _ = typedValue(v, typ) // empty cells stay blank
+			}
+		}
+		if err := sw.SetRow(cell, values); err != nil {
+			return fmt.Errorf("failed to write row %d: %w", i+1, err)
+		}
+	}
+
+	if err := sw.Flush(); err != nil {
+		return fmt.Errorf("failed to write sheet: %w", err)
+	}
+	if err := f.SaveAs(path); err != nil {
+		return fmt.Errorf("failed to save workbook: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,567 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// Transfer protocols offered in the UI.
+const (
+	ProtocolXmodem    = "XMODEM"
+	ProtocolXmodemCRC = "XMODEM-CRC"
+	ProtocolXmodem1K  = "XMODEM-1K"
+	ProtocolYmodem    = "YMODEM"
+)
+
+var transferProtocols = []string{ProtocolXmodem, ProtocolXmodemCRC, ProtocolXmodem1K, ProtocolYmodem}
+
+// XMODEM/YMODEM control bytes.
+const (
+	modemSOH = 0x01
+	modemSTX = 0x02
+	modemEOT = 0x04
+	modemACK = 0x06
+	modemNAK = 0x15
+	modemCAN = 0x18
+	modemSUB = 0x1A
+	modemCRC = 'C'
+)
+
+const (
+	modemMaxRetries   = 10
+	modemStartTimeout = 60 * time.Second
+	modemBlockTimeout = 10 * time.Second
+)
+
+var (
+	errModemBadPacket  = errors.New("bad packet")
+	errRemoteCancelled = errors.New("transfer cancelled by remote")
+)
+
+// TransferOptions configures an XMODEM or YMODEM transfer.
+type TransferOptions struct {
+	Protocol string   // one of transferProtocols
+	Files    []string // files to send; XMODEM sends only the first
+	SavePath string   // receive destination: a file for XMODEM, a directory for YMODEM
+}
+
+// TransferProgress reports the state of a running transfer.
+type TransferProgress struct {
+	File    string
+	Bytes   int64
+	Total   int64 // 0 when the size is unknown
+	Retries int
+}
+
+// modemConn wraps the port with the timing, cancellation and progress
+// bookkeeping shared by the senders and receivers.
+type modemConn struct {
+	rw       io.ReadWriter
+	cancel   <-chan struct{}
+	progress func(TransferProgress)
+	state    TransferProgress
+}
+
+func newModemConn(rw io.ReadWriter, progress func(TransferProgress), cancel <-chan struct{}) *modemConn {
+	return &modemConn{rw: rw, cancel: cancel, progress: progress}
+}
+
+func (c *modemConn) report() {
+	if c.progress != nil {
+		c.progress(c.state)
+	}
+}
+
+func (c *modemConn) retry() {
+	c.state.Retries++
+	c.report()
+}
+
+func (c *modemConn) write(data ...byte) error {
+	_, err := c.rw.Write(data)
+	return err
+}
+
+// abort tells the remote side to give up.
+func (c *modemConn) abort() {
+	c.write(modemCAN, modemCAN, modemCAN)
+}
+
+// read fills buf, relying on the port's read timeout to poll for cancellation.
+func (c *modemConn) read(buf []byte, timeout time.Duration) error {
+	return readFull(c.rw, buf, timeout, c.cancel)
+}
+
+func (c *modemConn) readByte(timeout time.Duration) (byte, error) {
+	var b [1]byte
+	err := c.read(b[:], timeout)
+	return b[0], err
+}
+
+// purge discards incoming bytes until the line has been quiet for a moment,
+// so a NAK is not sent in the middle of a packet.
+func (c *modemConn) purge() {
+	for {
+		if _, err := c.readByte(200 * time.Millisecond); err != nil {
+			return
+		}
+	}
+}
+
+// crc16XMODEM computes the CRC-16/XMODEM checksum (poly 0x1021, init 0).
+func crc16XMODEM(data []byte) uint16 {
+	var crc uint16
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+func checksum8(data []byte) byte {
+	var sum byte
+	for _, b := range data {
+		sum += b
+	}
+	return sum
+}
+
+// --- Sending ---
+
+// waitStart waits for the receiver to request a transfer and reports whether
+// it asked for CRC ('C') rather than checksum (NAK) mode.
+func (c *modemConn) waitStart() (bool, error) {
+	deadline := time.Now().Add(modemStartTimeout)
+	for time.Now().Before(deadline) {
+		b, err := c.readByte(time.Until(deadline))
+		if err != nil {
+			return false, err
+		}
+		switch b {
+		case modemCRC:
+			return true, nil
+		case modemNAK:
+			return false, nil
+		case modemCAN:
+			return false, errRemoteCancelled
+		}
+	}
+	return false, errReadTimeout
+}
+
+// sendBlock transmits one block, padding it to size, until it is acknowledged.
+func (c *modemConn) sendBlock(num byte, data []byte, size int, useCRC bool) error {
+	header := byte(modemSOH)
+	if size == 1024 {
+		header = modemSTX
+	}
+	payload := make([]byte, size)
+	copy(payload, data)
+	for i := len(data); i < size; i++ {
+		payload[i] = modemSUB
+	}
+
+	pkt := append([]byte{header, num, ^num}, payload...)
+	if useCRC {
+		crc := crc16XMODEM(payload)
+		pkt = append(pkt, byte(crc>>8), byte(crc))
+	} else {
+		pkt = append(pkt, checksum8(payload))
+	}
+
+	for attempt := 0; attempt < modemMaxRetries; attempt++ {
+		if attempt > 0 {
+			c.retry()
+		}
+		if _, err := c.rw.Write(pkt); err != nil {
+			return err
+		}
+		b, err := c.readByte(modemBlockTimeout)
+		if errors.Is(err, errReadTimeout) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		switch b {
+		case modemACK:
+			return nil
+		case modemCAN:
+			if next, _ := c.readByte(time.Second); next == modemCAN {
+				return errRemoteCancelled
+			}
+		}
+	}
+	return fmt.Errorf("block %d not acknowledged after %d attempts", num, modemMaxRetries)
+}
+
+// sendData transmits data as numbered blocks followed by the EOT handshake.
+func (c *modemConn) sendData(data []byte, blockSize int, useCRC bool) error {
+	num := byte(1)
+	for off := 0; off < len(data); {
+		size := blockSize
+		if size == 1024 && len(data)-off <= 128 {
+			size = 128
+		}
+		end := min(off+size, len(data))
+		if err := c.sendBlock(num, data[off:end], size, useCRC); err != nil {
+			return err
+		}
+		num++
+		off = end
+		c.state.Bytes = int64(off)
+		c.report()
+	}
+
+	// YMODEM receivers NAK the first EOT; keep sending until acknowledged.
+	for attempt := 0; attempt < modemMaxRetries; attempt++ {
+		if err := c.write(modemEOT); err != nil {
+			return err
+		}
+		b, err := c.readByte(modemBlockTimeout)
+		if err == nil && b == modemACK {
+			return nil
+		}
+		if err != nil && !errors.Is(err, errReadTimeout) {
+			return err
+		}
+	}
+	return fmt.Errorf("end of transmission not acknowledged")
+}
+
+// SendFiles transmits files to the remote receiver using the chosen protocol.
+func SendFiles(rw io.ReadWriter, opts TransferOptions, progress func(TransferProgress), cancel <-chan struct{}) error {
+	if len(opts.Files) == 0 {
+		return fmt.Errorf("no file selected")
+	}
+	c := newModemConn(rw, progress, cancel)
+	err := c.sendFiles(opts)
+	if errors.Is(err, errTransferCancelled) {
+		c.abort()
+	}
+	return err
+}
+
+func (c *modemConn) sendFiles(opts TransferOptions) error {
+	if opts.Protocol != ProtocolYmodem {
+		data, err := os.ReadFile(opts.Files[0])
+		if err != nil {
+			return fmt.Errorf("failed to read file: %w", err)
+		}
+		c.state = TransferProgress{File: filepath.Base(opts.Files[0]), Total: int64(len(data))}
+		c.report()
+
+		useCRC, err := c.waitStart()
+		if err != nil {
+			return fmt.Errorf("receiver did not start: %w", err)
+		}
+		blockSize := 128
+		if opts.Protocol == ProtocolXmodem1K && useCRC {
+			blockSize = 1024
+		}
+		return c.sendData(data, blockSize, useCRC)
+	}
+
+	for _, path := range opts.Files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read file: %w", err)
+		}
+		name := filepath.Base(path)
+		c.state = TransferProgress{File: name, Total: int64(len(data)), Retries: c.state.Retries}
+		c.report()
+
+		if _, err := c.waitStart(); err != nil {
+			return fmt.Errorf("receiver did not start: %w", err)
+		}
+		header := ymodemHeader(name, int64(len(data)))
+		if err := c.sendBlock(0, header, len(header), true); err != nil {
+			return err
+		}
+		if _, err := c.waitStart(); err != nil {
+			return fmt.Errorf("receiver did not request data: %w", err)
+		}
+		if err := c.sendData(data, 1024, true); err != nil {
+			return err
+		}
+	}
+
+	// An empty block 0 ends the batch.
+	if _, err := c.waitStart(); err != nil {
+		return fmt.Errorf("receiver did not request next file: %w", err)
+	}
+	return c.sendBlock(0, make([]byte, 128), 128, true)
+}
+
+// ymodemHeader builds a NUL-padded YMODEM block 0 carrying the file name and size.
+func ymodemHeader(name string, size int64) []byte {
+	info := append([]byte(name), 0)
+	info = append(info, strconv.FormatInt(size, 10)...)
+	blockSize := 128
+	if len(info) > 128 {
+		blockSize = 1024
+	}
+	header := make([]byte, blockSize)
+	copy(header, info)
+	return header
+}
+
+// --- Receiving ---
+
+// recvPacket reads one packet. eot is true when the sender ends the file.
+func (c *modemConn) recvPacket(useCRC bool, timeout time.Duration) (num byte, data []byte, eot bool, err error) {
+	header, err := c.readByte(timeout)
+	if err != nil {
+		return 0, nil, false, err
+	}
+
+	var size int
+	switch header {
+	case modemSOH:
+		size = 128
+	case modemSTX:
+		size = 1024
+	case modemEOT:
+		return 0, nil, true, nil
+	case modemCAN:
+		if next, _ := c.readByte(time.Second); next == modemCAN {
+			return 0, nil, false, errRemoteCancelled
+		}
+		return 0, nil, false, errModemBadPacket
+	default:
+		return 0, nil, false, errModemBadPacket
+	}
+
+	trailer := 1
+	if useCRC {
+		trailer = 2
+	}
+	buf := make([]byte, 2+size+trailer)
+	if err := c.read(buf, time.Second); err != nil {
+		if errors.Is(err, errReadTimeout) {
+			return 0, nil, false, errModemBadPacket
+		}
+		return 0, nil, false, err
+	}
+
+	num, payload := buf[0], buf[2:2+size]
+	if num != ^buf[1] {
+		return 0, nil, false, errModemBadPacket
+	}
+	if useCRC {
+		if crc16XMODEM(payload) != uint16(buf[2+size])<<8|uint16(buf[3+size]) {
+			return 0, nil, false, errModemBadPacket
+		}
+	} else if checksum8(payload) != buf[2+size] {
+		return 0, nil, false, errModemBadPacket
+	}
+	return num, payload, false, nil
+}
+
+// initiate keeps requesting a transfer until the first packet arrives. In
+// XMODEM-CRC mode it falls back to checksum mode if the sender ignores 'C'.
+func (c *modemConn) initiate(useCRC *bool, fallback bool) (num byte, data []byte, eot bool, err error) {
+	for attempt := 0; attempt < modemMaxRetries; attempt++ {
+		if *useCRC && fallback && attempt == 4 {
+			*useCRC = false
+		}
+		start := byte(modemNAK)
+		if *useCRC {
+			start = modemCRC
+		}
+		if err := c.write(start); err != nil {
+			return 0, nil, false, err
+		}
+		num, data, eot, err = c.recvPacket(*useCRC, 3*time.Second)
+		if !errors.Is(err, errReadTimeout) {
+			return num, data, eot, err
+		}
+	}
+	return 0, nil, false, fmt.Errorf("sender did not start: %w", errReadTimeout)
+}
+
+// receiveData receives numbered data blocks starting at 1 and hands each
+// payload to sink until the sender signals end of transmission.
+func (c *modemConn) receiveData(useCRC, fallback, ymodem bool, sink func([]byte) error) error {
+	num, data, eot, err := c.initiate(&useCRC, fallback)
+	expected := byte(1)
+	failures := 0
+	eotSeen := false
+	for {
+		switch {
+		case errors.Is(err, errReadTimeout), errors.Is(err, errModemBadPacket):
+			failures++
+			c.retry()
+			if failures > modemMaxRetries {
+				c.abort()
+				return fmt.Errorf("too many errors receiving block %d", expected)
+			}
+			c.purge()
+			if err := c.write(modemNAK); err != nil {
+				return err
+			}
+		case err != nil:
+			return err
+		case eot:
+			// YMODEM senders expect the first EOT to be NAKed.
+			if ymodem && !eotSeen {
+				eotSeen = true
+				if err := c.write(modemNAK); err != nil {
+					return err
+				}
+				break
+			}
+			return c.write(modemACK)
+		case num == expected-1:
+			// Duplicate of the last block: our ACK was lost.
+			if err := c.write(modemACK); err != nil {
+				return err
+			}
+		case num != expected:
+			c.abort()
+			return fmt.Errorf("block sequence error: expected %d, got %d", expected, num)
+		default:
+			if err := sink(data); err != nil {
+				c.abort()
+				return err
+			}
+			if err := c.write(modemACK); err != nil {
+				return err
+			}
+			expected++
+			failures = 0
+		}
+		num, data, eot, err = c.recvPacket(useCRC, modemBlockTimeout)
+	}
+}
+
+// ReceiveFiles receives files from the remote sender and returns the paths
+// written.
+func ReceiveFiles(rw io.ReadWriter, opts TransferOptions, progress func(TransferProgress), cancel <-chan struct{}) ([]string, error) {
+	c := newModemConn(rw, progress, cancel)
+	var saved []string
+	var err error
+	if opts.Protocol == ProtocolYmodem {
+		saved, err = c.receiveYmodem(opts.SavePath)
+	} else {
+		err = c.receiveXmodem(opts)
+		if err == nil {
+			saved = []string{opts.SavePath}
+		}
+	}
+	if errors.Is(err, errTransferCancelled) {
+		c.abort()
+	}
+	return saved, err
+}
+
+func (c *modemConn) receiveXmodem(opts TransferOptions) error {
+	c.state = TransferProgress{File: filepath.Base(opts.SavePath)}
+	c.report()
+
+	var buf bytes.Buffer
+	useCRC := opts.Protocol != ProtocolXmodem
+	err := c.receiveData(useCRC, useCRC, false, func(data []byte) error {
+		buf.Write(data)
+		c.state.Bytes = int64(buf.Len())
+		c.report()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// XMODEM carries no file size, so strip the final block's padding.
+	data := bytes.TrimRight(buf.Bytes(), string([]byte{modemSUB}))
+	if err := os.WriteFile(opts.SavePath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	return nil
+}
+
+func (c *modemConn) receiveYmodem(dir string) ([]string, error) {
+	var saved []string
+	failures := 0
+	for {
+		useCRC := true
+		num, data, eot, err := c.initiate(&useCRC, false)
+		if err != nil && !errors.Is(err, errModemBadPacket) {
+			return saved, err
+		}
+		if err != nil || eot || num != 0 {
+			// A repeated EOT means our final ACK was lost.
+			if eot {
+				c.write(modemACK)
+			}
+			failures++
+			c.retry()
+			if failures > modemMaxRetries {
+				c.abort()
+				return saved, fmt.Errorf("no valid file header received")
+			}
+			c.purge()
+			continue
+		}
+		failures = 0
+		if err := c.write(modemACK); err != nil {
+			return saved, err
+		}
+
+		name, size := parseYmodemHeader(data)
+		if name == "" {
+			return saved, nil
+		}
+		c.state = TransferProgress{File: name, Total: size, Retries: c.state.Retries}
+		c.report()
+
+		path := filepath.Join(dir, filepath.Base(name))
+		f, err := os.Create(path)
+		if err != nil {
+			c.abort()
+			return saved, fmt.Errorf("failed to create file: %w", err)
+		}
+		var written int64
+		err = c.receiveData(true, false, true, func(data []byte) error {
+			if size > 0 && written+int64(len(data)) > size {
+				data = data[:size-written]
+			}
+			n, err := f.Write(data)
+			written += int64(n)
+			c.state.Bytes = written
+			c.report()
+			return err
+		})
+		f.Close()
+		if err != nil {
+			return saved, err
+		}
+		saved = append(saved, path)
+	}
+}
+
+// parseYmodemHeader extracts the file name and size from a YMODEM block 0.
+// The size is 0 when the sender omitted it.
+func parseYmodemHeader(block []byte) (string, int64) {
+	name, rest, _ := bytes.Cut(block, []byte{0})
+	if len(name) == 0 {
+		return "", 0
+	}
+	fields := bytes.Fields(bytes.TrimRight(rest, "\x00"))
+	var size int64
+	if len(fields) > 0 {
+		size, _ = strconv.ParseInt(string(fields[0]), 10, 64)
+	}
+	return string(name), size
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bytes"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// loopbackEnd is one side of an in-memory serial link. Like a port with a
+// read timeout set, Read returns (0, nil) when nothing arrives in time.
+type loopbackEnd struct {
+	in  chan []byte
+	out chan []byte
+	buf []byte
+
+	// corrupt, if set, may alter each chunk written.
+	corrupt func([]byte) []byte
+}
+
+func newLoopback() (*loopbackEnd, *loopbackEnd) {
+	ab, ba := make(chan []byte, 4096), make(chan []byte, 4096)
+	return &loopbackEnd{in: ba, out: ab}, &loopbackEnd{in: ab, out: ba}
+}
+
+func (l *loopbackEnd) Read(p []byte) (int, error) {
+	if len(l.buf) == 0 {
+		select {
+		case data := <-l.in:
+			l.buf = data
+		case <-time.After(5 * time.Millisecond):
+			return 0, nil
+		}
+	}
+	n := copy(p, l.buf)
+	l.buf = l.buf[n:]
+	return n, nil
+}
+
+func (l *loopbackEnd) Write(p []byte) (int, error) {
+	data := append([]byte(nil), p...)
+	if l.corrupt != nil {
+		data = l.corrupt(data)
+	}
+	l.out <- data
+	return len(p), nil
+}
+
+// ResetInputBuffer discards everything received but not yet read.
+func (l *loopbackEnd) ResetInputBuffer() error {
+	l.buf = nil
+	for {
+		select {
+		case <-l.in:
+		default:
+			return nil
+		}
+	}
+}
+
+// testPayload returns n pseudo-random bytes that don't end in XMODEM padding.
+func testPayload(seed int64, n int) []byte {
+	data := make([]byte, n)
+	rand.New(rand.NewSource(seed)).Read(data)
+	if data[n-1] == modemSUB {
+		data[n-1] = 0
+	}
+	return data
+}
+
+// transfer runs a sender and receiver against each other and returns the
+// receiver's saved paths and progress.
+func transfer(t *testing.T, sender, receiver *loopbackEnd, send, recv TransferOptions) ([]string, TransferProgress) {
+	t.Helper()
+	var wg sync.WaitGroup
+	var sendErr error
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		sendErr = SendFiles(sender, send, nil, nil)
+	}()
+	var last TransferProgress
+	saved, recvErr := ReceiveFiles(receiver, recv, func(p TransferProgress) { last = p }, nil)
+	wg.Wait()
+	if sendErr != nil {
+		t.Fatalf("send: %v", sendErr)
+	}
+	if recvErr != nil {
+		t.Fatalf("receive: %v", recvErr)
+	}
+	return saved, last
+}
+
+func TestXmodemLoopback(t *testing.T) {
+	for _, protocol := range []string{ProtocolXmodem, ProtocolXmodemCRC, ProtocolXmodem1K} {
+		t.Run(protocol, func(t *testing.T) {
+			dir := t.TempDir()
+			data := testPayload(1, 3000)
+			src := filepath.Join(dir, "src.bin")
+			if err := os.WriteFile(src, data, 0644); err != nil {
+				t.Fatal(err)
+			}
+			dst := filepath.Join(dir, "dst.bin")
+
+			a, b := newLoopback()
+			transfer(t, a, b,
+				TransferOptions{Protocol: protocol, Files: []string{src}},
+				TransferOptions{Protocol: protocol, SavePath: dst})
+
+			got, err := os.ReadFile(dst)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, data) {
+				t.Fatalf("received %d bytes, want %d identical bytes", len(got), len(data))
+			}
+		})
+	}
+}
+
+func TestYmodemLoopbackBatch(t *testing.T) {
+	srcDir, dstDir := t.TempDir(), t.TempDir()
+	files := map[string][]byte{
+		"first.bin":  testPayload(2, 2500),
+		"second.txt": []byte("hello\n"),
+	}
+	var paths []string
+	for name, data := range files {
+		path := filepath.Join(srcDir, name)
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+
+	a, b := newLoopback()
+	saved, _ := transfer(t, a, b,
+		TransferOptions{Protocol: ProtocolYmodem, Files: paths},
+		TransferOptions{Protocol: ProtocolYmodem, SavePath: dstDir})
+
+	if len(saved) != len(files) {
+		t.Fatalf("saved %v, want %d files", saved, len(files))
+	}
+	for name, want := range files {
+		got, err := os.ReadFile(filepath.Join(dstDir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s: received %d bytes, want %d identical bytes", name, len(got), len(want))
+		}
+	}
+}
+
+func TestXmodemRetriesCorruptBlock(t *testing.T) {
+	dir := t.TempDir()
+	data := testPayload(3, 1000)
+	src := filepath.Join(dir, "src.bin")
+	if err := os.WriteFile(src, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	dst := filepath.Join(dir, "dst.bin")
+
+	a, b := newLoopback()
+	corrupted := false
+	a.corrupt = func(p []byte) []byte {
+		// Flip a payload bit in the second block, once.
+		if !corrupted && len(p) > 10 && p[0] == modemSOH && p[1] == 2 {
+			corrupted = true
+			p[10] ^= 0x01
+		}
+		return p
+	}
+	_, progress := transfer(t, a, b,
+		TransferOptions{Protocol: ProtocolXmodemCRC, Files: []string{src}},
+		TransferOptions{Protocol: ProtocolXmodemCRC, SavePath: dst})
+
+	if !corrupted {
+		t.Fatal("block 2 was never sent")
+	}
+	if progress.Retries == 0 {
+		t.Error("corrupt block was accepted without a retry")
+	}
+	got, err := os.ReadFile(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatalf("received %d bytes, want %d identical bytes", len(got), len(data))
+	}
+}
+
+func TestReadFullTimesOut(t *testing.T) {
+	a, _ := newLoopback()
+	start := time.Now()
+	err := readFull(a, make([]byte, 4), 50*time.Millisecond, nil)
+	if err != errReadTimeout {
+		t.Fatalf("got %v, want errReadTimeout", err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("gave up after %s", elapsed)
+	}
+}